@@ -0,0 +1,788 @@
+package memory
+
+/*
+ * @abstract the redis commands supported by Memory
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type statusReply string
+type errReply string
+
+const (
+	kindString = iota
+	kindHash
+	kindZset
+)
+
+var (
+	replyOK = statusReply("OK")
+
+	errWrongType   = errReply("WRONGTYPE Operation against a key holding the wrong kind of value")
+	errSyntax      = errReply("ERR syntax error")
+	errNotInteger  = errReply("ERR value is not an integer or out of range")
+	errNotFloat    = errReply("ERR value is not a valid float")
+	errMinMaxFloat = errReply("ERR min or max is not a float")
+)
+
+type entry struct {
+	kind     int
+	str      string
+	hash     map[string]string
+	zset     map[string]float64
+	expireAt time.Time
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.expireAt.IsZero() && !now.Before(e.expireAt)
+}
+
+type command struct {
+	arity int // the minimal count of args without the name.
+	fn    func(m *Memory, args []string) any
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"GET":    {1, cmdGet},
+		"SET":    {2, cmdSet},
+		"INCR":   {1, cmdIncr},
+		"DECR":   {1, cmdDecr},
+		"INCRBY": {2, cmdIncrBy},
+		"DECRBY": {2, cmdDecrBy},
+
+		"DEL":     {1, cmdDel},
+		"EXISTS":  {1, cmdExists},
+		"EXPIRE":  {2, cmdExpire},
+		"PEXPIRE": {2, cmdPExpire},
+		"TTL":     {1, cmdTTL},
+		"PTTL":    {1, cmdPTTL},
+		"PERSIST": {1, cmdPersist},
+		"TIME":    {0, cmdTime},
+		"PUBLISH": {2, cmdPublish},
+
+		"HGET":    {2, cmdHGet},
+		"HSET":    {3, cmdHSet},
+		"HMSET":   {3, cmdHMSet},
+		"HMGET":   {2, cmdHMGet},
+		"HINCRBY": {3, cmdHIncrBy},
+		"HDEL":    {2, cmdHDel},
+		"HLEN":    {1, cmdHLen},
+		"HEXISTS": {2, cmdHExists},
+		"HGETALL": {1, cmdHGetAll},
+
+		"ZADD":             {3, cmdZAdd},
+		"ZREM":             {2, cmdZRem},
+		"ZCARD":            {1, cmdZCard},
+		"ZSCORE":           {2, cmdZScore},
+		"ZRANGE":           {3, cmdZRange},
+		"ZRANGEBYSCORE":    {3, cmdZRangeByScore},
+		"ZREMRANGEBYSCORE": {3, cmdZRemRangeByScore},
+		"ZREMRANGEBYRANK":  {3, cmdZRemRangeByRank},
+	}
+}
+
+// do runs one command under the lock.
+func (m *Memory) do(name string, args ...string) any {
+	cmd, ok := commands[strings.ToUpper(name)]
+	if !ok {
+		return errReply("ERR unknown command '" + name + "'")
+	}
+	if len(args) < cmd.arity {
+		return errReply("ERR wrong number of arguments for '" + strings.ToLower(name) + "' command")
+	}
+	return cmd.fn(m, args)
+}
+
+// lookup returns the living entry of key.
+func (m *Memory) lookup(key string) *entry {
+	e, ok := m.data[key]
+	if !ok {
+		return nil
+	}
+	if e.expired(time.Now()) {
+		delete(m.data, key)
+		return nil
+	}
+	return e
+}
+
+// lookupKind returns the living entry of key with the kind, creating it if create is true.
+func (m *Memory) lookupKind(key string, kind int, create bool) (e *entry, err any) {
+	if e = m.lookup(key); e != nil {
+		if e.kind != kind {
+			return nil, errWrongType
+		}
+		return
+	}
+	if !create {
+		return
+	}
+	e = &entry{kind: kind}
+	switch kind {
+	case kindHash:
+		e.hash = make(map[string]string)
+	case kindZset:
+		e.zset = make(map[string]float64)
+	}
+	m.data[key] = e
+	return
+}
+
+// dropEmpty removes the hash or zset without any field.
+func (m *Memory) dropEmpty(key string, e *entry) {
+	if len(e.hash) == 0 && len(e.zset) == 0 && e.kind != kindString {
+		delete(m.data, key)
+	}
+}
+
+// ========== string ==========
+func cmdGet(m *Memory, args []string) any {
+	e, err := m.lookupKind(args[0], kindString, false)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return nil
+	}
+	return e.str
+}
+
+func cmdSet(m *Memory, args []string) any {
+	var nx, xx, keepTTL bool
+	var ttl time.Duration
+	for i := 2; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "KEEPTTL":
+			keepTTL = true
+		case "EX", "PX":
+			if i+1 >= len(args) {
+				return errSyntax
+			}
+			n, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil {
+				return errNotInteger
+			}
+			if n <= 0 {
+				return errReply("ERR invalid expire time in 'set' command")
+			}
+			ttl = time.Duration(n) * time.Millisecond
+			if strings.ToUpper(args[i]) == "EX" {
+				ttl = time.Duration(n) * time.Second
+			}
+			i++
+		default:
+			return errSyntax
+		}
+	}
+	if nx && xx {
+		return errSyntax
+	}
+
+	old := m.lookup(args[0])
+	if (nx && old != nil) || (xx && old == nil) {
+		return nil
+	}
+
+	e := &entry{kind: kindString, str: args[1]}
+	if ttl > 0 {
+		e.expireAt = time.Now().Add(ttl)
+	} else if keepTTL && old != nil {
+		e.expireAt = old.expireAt
+	}
+	m.data[args[0]] = e
+	return replyOK
+}
+
+func incrBy(m *Memory, key string, by int64) any {
+	e, err := m.lookupKind(key, kindString, false)
+	if err != nil {
+		return err
+	}
+	var n int64
+	if e != nil {
+		var perr error
+		if n, perr = strconv.ParseInt(e.str, 10, 64); perr != nil {
+			return errNotInteger
+		}
+	} else {
+		e = &entry{kind: kindString}
+		m.data[key] = e
+	}
+	n += by
+	e.str = strconv.FormatInt(n, 10)
+	return n
+}
+
+func cmdIncr(m *Memory, args []string) any {
+	return incrBy(m, args[0], 1)
+}
+
+func cmdDecr(m *Memory, args []string) any {
+	return incrBy(m, args[0], -1)
+}
+
+func cmdIncrBy(m *Memory, args []string) any {
+	n, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return errNotInteger
+	}
+	return incrBy(m, args[0], n)
+}
+
+func cmdDecrBy(m *Memory, args []string) any {
+	n, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return errNotInteger
+	}
+	return incrBy(m, args[0], -n)
+}
+
+// ========== key ==========
+func cmdDel(m *Memory, args []string) any {
+	var n int64
+	for _, k := range args {
+		if m.lookup(k) != nil {
+			delete(m.data, k)
+			n++
+		}
+	}
+	return n
+}
+
+func cmdExists(m *Memory, args []string) any {
+	var n int64
+	for _, k := range args {
+		if m.lookup(k) != nil {
+			n++
+		}
+	}
+	return n
+}
+
+func expire(m *Memory, key, ttl string, unit time.Duration) any {
+	n, err := strconv.ParseInt(ttl, 10, 64)
+	if err != nil {
+		return errNotInteger
+	}
+	e := m.lookup(key)
+	if e == nil {
+		return int64(0)
+	}
+	if n <= 0 {
+		delete(m.data, key)
+		return int64(1)
+	}
+	e.expireAt = time.Now().Add(time.Duration(n) * unit)
+	return int64(1)
+}
+
+func cmdExpire(m *Memory, args []string) any {
+	return expire(m, args[0], args[1], time.Second)
+}
+
+func cmdPExpire(m *Memory, args []string) any {
+	return expire(m, args[0], args[1], time.Millisecond)
+}
+
+func ttl(m *Memory, key string, unit time.Duration) any {
+	e := m.lookup(key)
+	if e == nil {
+		return int64(-2)
+	}
+	if e.expireAt.IsZero() {
+		return int64(-1)
+	}
+	d := time.Until(e.expireAt)
+	return int64((d + unit/2) / unit)
+}
+
+func cmdTTL(m *Memory, args []string) any {
+	return ttl(m, args[0], time.Second)
+}
+
+func cmdPTTL(m *Memory, args []string) any {
+	return ttl(m, args[0], time.Millisecond)
+}
+
+func cmdPersist(m *Memory, args []string) any {
+	e := m.lookup(args[0])
+	if e == nil || e.expireAt.IsZero() {
+		return int64(0)
+	}
+	e.expireAt = time.Time{}
+	return int64(1)
+}
+
+func cmdTime(m *Memory, args []string) any {
+	now := time.Now()
+	return []any{
+		strconv.FormatInt(now.Unix(), 10),
+		strconv.FormatInt(int64(now.Nanosecond()/1000), 10),
+	}
+}
+
+// ========== hash ==========
+func cmdHGet(m *Memory, args []string) any {
+	e, err := m.lookupKind(args[0], kindHash, false)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return nil
+	}
+	if v, ok := e.hash[args[1]]; ok {
+		return v
+	}
+	return nil
+}
+
+func cmdHSet(m *Memory, args []string) any {
+	if len(args)%2 != 1 {
+		return errReply("ERR wrong number of arguments for 'hset' command")
+	}
+	e, err := m.lookupKind(args[0], kindHash, true)
+	if err != nil {
+		return err
+	}
+	var n int64
+	for i := 1; i < len(args); i += 2 {
+		if _, ok := e.hash[args[i]]; !ok {
+			n++
+		}
+		e.hash[args[i]] = args[i+1]
+	}
+	return n
+}
+
+func cmdHMSet(m *Memory, args []string) any {
+	if rst, ok := cmdHSet(m, args).(errReply); ok {
+		return rst
+	}
+	return replyOK
+}
+
+func cmdHMGet(m *Memory, args []string) any {
+	e, err := m.lookupKind(args[0], kindHash, false)
+	if err != nil {
+		return err
+	}
+	rst := make([]any, 0, len(args)-1)
+	for _, f := range args[1:] {
+		if e == nil {
+			rst = append(rst, nil)
+			continue
+		}
+		if v, ok := e.hash[f]; ok {
+			rst = append(rst, v)
+			continue
+		}
+		rst = append(rst, nil)
+	}
+	return rst
+}
+
+func cmdHIncrBy(m *Memory, args []string) any {
+	by, perr := strconv.ParseInt(args[2], 10, 64)
+	if perr != nil {
+		return errNotInteger
+	}
+	e, err := m.lookupKind(args[0], kindHash, true)
+	if err != nil {
+		return err
+	}
+	var n int64
+	if v, ok := e.hash[args[1]]; ok {
+		if n, perr = strconv.ParseInt(v, 10, 64); perr != nil {
+			return errReply("ERR hash value is not an integer")
+		}
+	}
+	n += by
+	e.hash[args[1]] = strconv.FormatInt(n, 10)
+	return n
+}
+
+func cmdHDel(m *Memory, args []string) any {
+	e, err := m.lookupKind(args[0], kindHash, false)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return int64(0)
+	}
+	var n int64
+	for _, f := range args[1:] {
+		if _, ok := e.hash[f]; ok {
+			delete(e.hash, f)
+			n++
+		}
+	}
+	m.dropEmpty(args[0], e)
+	return n
+}
+
+func cmdHLen(m *Memory, args []string) any {
+	e, err := m.lookupKind(args[0], kindHash, false)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return int64(0)
+	}
+	return int64(len(e.hash))
+}
+
+func cmdHExists(m *Memory, args []string) any {
+	e, err := m.lookupKind(args[0], kindHash, false)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return int64(0)
+	}
+	if _, ok := e.hash[args[1]]; ok {
+		return int64(1)
+	}
+	return int64(0)
+}
+
+func cmdHGetAll(m *Memory, args []string) any {
+	e, err := m.lookupKind(args[0], kindHash, false)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return []any{}
+	}
+	fs := make([]string, 0, len(e.hash))
+	for f := range e.hash {
+		fs = append(fs, f)
+	}
+	sort.Strings(fs)
+	rst := make([]any, 0, len(fs)*2)
+	for _, f := range fs {
+		rst = append(rst, f, e.hash[f])
+	}
+	return rst
+}
+
+// ========== zset ==========
+type zmember struct {
+	member string
+	score  float64
+}
+
+// sorted returns the members ordered by score then member.
+func (e *entry) sorted() (ms []zmember) {
+	ms = make([]zmember, 0, len(e.zset))
+	for k, v := range e.zset {
+		ms = append(ms, zmember{k, v})
+	}
+	sort.Slice(ms, func(i, j int) bool {
+		if ms[i].score != ms[j].score {
+			return ms[i].score < ms[j].score
+		}
+		return ms[i].member < ms[j].member
+	})
+	return
+}
+
+// parseScore parses the score bound such as "-inf", "+inf", "(1.5" and "2".
+func parseScore(s string) (f float64, exclusive bool, ok bool) {
+	if strings.HasPrefix(s, "(") {
+		exclusive = true
+		s = s[1:]
+	}
+	switch strings.ToLower(s) {
+	case "-inf":
+		return math.Inf(-1), exclusive, true
+	case "+inf", "inf":
+		return math.Inf(1), exclusive, true
+	}
+	var err error
+	if f, err = strconv.ParseFloat(s, 64); err != nil {
+		return
+	}
+	ok = true
+	return
+}
+
+type scoreRange struct {
+	min, max       float64
+	minExc, maxExc bool
+}
+
+func newScoreRange(min, max string) (r scoreRange, ok bool) {
+	var ok1, ok2 bool
+	r.min, r.minExc, ok1 = parseScore(min)
+	r.max, r.maxExc, ok2 = parseScore(max)
+	ok = ok1 && ok2
+	return
+}
+
+func (r scoreRange) contains(f float64) bool {
+	if f < r.min || (r.minExc && f == r.min) {
+		return false
+	}
+	if f > r.max || (r.maxExc && f == r.max) {
+		return false
+	}
+	return true
+}
+
+func cmdZAdd(m *Memory, args []string) any {
+	var nx, xx bool
+	i := 1
+	for ; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "NX":
+			nx = true
+			continue
+		case "XX":
+			xx = true
+			continue
+		}
+		break
+	}
+	pairs := args[i:]
+	if len(pairs) == 0 || len(pairs)%2 != 0 || (nx && xx) {
+		return errSyntax
+	}
+	scores := make([]float64, 0, len(pairs)/2)
+	for j := 0; j < len(pairs); j += 2 {
+		f, exc, ok := parseScore(pairs[j])
+		if !ok || exc {
+			return errNotFloat
+		}
+		scores = append(scores, f)
+	}
+
+	e, err := m.lookupKind(args[0], kindZset, true)
+	if err != nil {
+		return err
+	}
+	var n int64
+	for j := 0; j < len(pairs); j += 2 {
+		member := pairs[j+1]
+		_, exists := e.zset[member]
+		if (nx && exists) || (xx && !exists) {
+			continue
+		}
+		if !exists {
+			n++
+		}
+		e.zset[member] = scores[j/2]
+	}
+	m.dropEmpty(args[0], e)
+	return n
+}
+
+func cmdZRem(m *Memory, args []string) any {
+	e, err := m.lookupKind(args[0], kindZset, false)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return int64(0)
+	}
+	var n int64
+	for _, f := range args[1:] {
+		if _, ok := e.zset[f]; ok {
+			delete(e.zset, f)
+			n++
+		}
+	}
+	m.dropEmpty(args[0], e)
+	return n
+}
+
+func cmdZCard(m *Memory, args []string) any {
+	e, err := m.lookupKind(args[0], kindZset, false)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return int64(0)
+	}
+	return int64(len(e.zset))
+}
+
+func cmdZScore(m *Memory, args []string) any {
+	e, err := m.lookupKind(args[0], kindZset, false)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return nil
+	}
+	if f, ok := e.zset[args[1]]; ok {
+		return formatFloat(f)
+	}
+	return nil
+}
+
+func cmdZRange(m *Memory, args []string) any {
+	start, err1 := strconv.Atoi(args[1])
+	stop, err2 := strconv.Atoi(args[2])
+	if err1 != nil || err2 != nil {
+		return errNotInteger
+	}
+	var withScores bool
+	for _, a := range args[3:] {
+		if strings.ToUpper(a) != "WITHSCORES" {
+			return errSyntax
+		}
+		withScores = true
+	}
+
+	e, err := m.lookupKind(args[0], kindZset, false)
+	if err != nil {
+		return err
+	}
+	rst := []any{}
+	if e == nil {
+		return rst
+	}
+	ms := e.sorted()
+	start, stop = rankRange(start, stop, len(ms))
+	for i := start; i <= stop; i++ {
+		rst = append(rst, ms[i].member)
+		if withScores {
+			rst = append(rst, formatFloat(ms[i].score))
+		}
+	}
+	return rst
+}
+
+// rankRange converts the negative ranks and clamps them into [0, l).
+func rankRange(start, stop, l int) (int, int) {
+	if start < 0 {
+		start += l
+	}
+	if stop < 0 {
+		stop += l
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= l {
+		stop = l - 1
+	}
+	return start, stop
+}
+
+func cmdZRangeByScore(m *Memory, args []string) any {
+	r, ok := newScoreRange(args[1], args[2])
+	if !ok {
+		return errMinMaxFloat
+	}
+	var withScores bool
+	offset, count := 0, -1
+	for i := 3; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "WITHSCORES":
+			withScores = true
+		case "LIMIT":
+			if i+2 >= len(args) {
+				return errSyntax
+			}
+			var err1, err2 error
+			offset, err1 = strconv.Atoi(args[i+1])
+			count, err2 = strconv.Atoi(args[i+2])
+			if err1 != nil || err2 != nil {
+				return errNotInteger
+			}
+			i += 2
+		default:
+			return errSyntax
+		}
+	}
+
+	e, err := m.lookupKind(args[0], kindZset, false)
+	if err != nil {
+		return err
+	}
+	rst := []any{}
+	if e == nil {
+		return rst
+	}
+	for _, z := range e.sorted() {
+		if !r.contains(z.score) {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		if count == 0 {
+			break
+		}
+		count--
+		rst = append(rst, z.member)
+		if withScores {
+			rst = append(rst, formatFloat(z.score))
+		}
+	}
+	return rst
+}
+
+func cmdZRemRangeByScore(m *Memory, args []string) any {
+	r, ok := newScoreRange(args[1], args[2])
+	if !ok {
+		return errMinMaxFloat
+	}
+	e, err := m.lookupKind(args[0], kindZset, false)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return int64(0)
+	}
+	var n int64
+	for k, v := range e.zset {
+		if r.contains(v) {
+			delete(e.zset, k)
+			n++
+		}
+	}
+	m.dropEmpty(args[0], e)
+	return n
+}
+
+func cmdZRemRangeByRank(m *Memory, args []string) any {
+	start, err1 := strconv.Atoi(args[1])
+	stop, err2 := strconv.Atoi(args[2])
+	if err1 != nil || err2 != nil {
+		return errNotInteger
+	}
+	e, err := m.lookupKind(args[0], kindZset, false)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return int64(0)
+	}
+	ms := e.sorted()
+	start, stop = rankRange(start, stop, len(ms))
+	var n int64
+	for i := start; i <= stop; i++ {
+		delete(e.zset, ms[i].member)
+		n++
+	}
+	m.dropEmpty(args[0], e)
+	return n
+}
@@ -0,0 +1,5 @@
+module github.com/neo532/gokit/database/memory
+
+go 1.23.1
+
+require github.com/yuin/gopher-lua v1.1.1
@@ -0,0 +1,231 @@
+package memory
+
+/*
+ * @abstract the lua runtime of Memory, converting values like redis does
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+func (m *Memory) newState() (L *lua.LState) {
+	L = lua.NewState(lua.Options{SkipOpenLibs: true})
+	for _, lib := range []struct {
+		name string
+		fn   lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.fn))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+
+	rds := L.NewTable()
+	L.SetField(rds, "call", L.NewFunction(m.luaCall(true)))
+	L.SetField(rds, "pcall", L.NewFunction(m.luaCall(false)))
+	L.SetField(rds, "error_reply", L.NewFunction(func(L *lua.LState) int {
+		t := L.NewTable()
+		L.SetField(t, "err", lua.LString(L.CheckString(1)))
+		L.Push(t)
+		return 1
+	}))
+	L.SetField(rds, "status_reply", L.NewFunction(func(L *lua.LState) int {
+		t := L.NewTable()
+		L.SetField(t, "ok", lua.LString(L.CheckString(1)))
+		L.Push(t)
+		return 1
+	}))
+	L.SetGlobal("redis", rds)
+	return
+}
+
+func (m *Memory) compile(script string) (proto *lua.FunctionProto, err error) {
+	if proto = m.scripts[script]; proto != nil {
+		return
+	}
+
+	chunk, err := parse.Parse(strings.NewReader(script), "script")
+	if err != nil {
+		return
+	}
+	if proto, err = lua.Compile(chunk, "script"); err != nil {
+		return
+	}
+	m.scripts[script] = proto
+	return
+}
+
+func (m *Memory) eval(script string, keys []string, args []any) (rst any, err error) {
+	var proto *lua.FunctionProto
+	if proto, err = m.compile(script); err != nil {
+		return
+	}
+
+	L := m.state
+	ks := L.NewTable()
+	for _, k := range keys {
+		ks.Append(lua.LString(k))
+	}
+	as := L.NewTable()
+	for _, a := range args {
+		as.Append(lua.LString(toString(a)))
+	}
+	L.SetGlobal("KEYS", ks)
+	L.SetGlobal("ARGV", as)
+
+	L.Push(L.NewFunctionFromProto(proto))
+	if err = L.PCall(0, 1, nil); err != nil {
+		return
+	}
+	ret := L.Get(-1)
+	L.Pop(1)
+	return fromLua(ret)
+}
+
+func (m *Memory) luaCall(raise bool) lua.LGFunction {
+	return func(L *lua.LState) int {
+		n := L.GetTop()
+		if n == 0 {
+			L.RaiseError("Please specify at least one argument for redis.call()")
+		}
+		args := make([]string, 0, n)
+		for i := 1; i <= n; i++ {
+			switch v := L.Get(i).(type) {
+			case lua.LString:
+				args = append(args, string(v))
+			case lua.LNumber:
+				args = append(args, formatFloat(float64(v)))
+			default:
+				L.RaiseError("Lua redis() command arguments must be strings or integers")
+			}
+		}
+
+		rst := m.do(args[0], args[1:]...)
+		if e, ok := rst.(errReply); ok && raise {
+			L.RaiseError("%s", string(e))
+		}
+		L.Push(toLua(L, rst))
+		return 1
+	}
+}
+
+// toLua converts the reply of command to lua value like redis does.
+func toLua(L *lua.LState, v any) lua.LValue {
+	switch r := v.(type) {
+	case nil:
+		return lua.LFalse
+	case int64:
+		return lua.LNumber(r)
+	case string:
+		return lua.LString(r)
+	case statusReply:
+		t := L.NewTable()
+		L.SetField(t, "ok", lua.LString(r))
+		return t
+	case errReply:
+		t := L.NewTable()
+		L.SetField(t, "err", lua.LString(r))
+		return t
+	case []any:
+		t := L.NewTable()
+		for _, o := range r {
+			t.Append(toLua(L, o))
+		}
+		return t
+	}
+	return lua.LFalse
+}
+
+// fromLua converts the lua value to the reply of script like redis does.
+func fromLua(v lua.LValue) (rst any, err error) {
+	switch r := v.(type) {
+	case lua.LNumber:
+		rst = int64(r)
+	case lua.LString:
+		rst = string(r)
+	case lua.LBool:
+		if r {
+			rst = int64(1)
+		}
+	case *lua.LTable:
+		if s, ok := r.RawGetString("err").(lua.LString); ok {
+			err = errors.New(string(s))
+			return
+		}
+		if s, ok := r.RawGetString("ok").(lua.LString); ok {
+			rst = string(s)
+			return
+		}
+		vs := make([]any, 0, r.Len())
+		for i := 1; ; i++ {
+			o := r.RawGetInt(i)
+			if o == lua.LNil {
+				break
+			}
+			var e any
+			if e, err = fromLua(o); err != nil {
+				return
+			}
+			vs = append(vs, e)
+		}
+		rst = vs
+	}
+	return
+}
+
+func toString(v any) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	case int:
+		return strconv.Itoa(s)
+	case int32:
+		return strconv.FormatInt(int64(s), 10)
+	case int64:
+		return strconv.FormatInt(s, 10)
+	case uint:
+		return strconv.FormatUint(uint64(s), 10)
+	case uint32:
+		return strconv.FormatUint(uint64(s), 10)
+	case uint64:
+		return strconv.FormatUint(s, 10)
+	case float32:
+		return strconv.FormatFloat(float64(s), 'f', -1, 64)
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	case bool:
+		if s {
+			return "1"
+		}
+		return "0"
+	case time.Duration:
+		return strconv.FormatInt(int64(s), 10)
+	}
+	return fmt.Sprint(v)
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,159 @@
+package memory
+
+/*
+ * @abstract in-process db evaluating the redis lua scripts
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Nil is returned when the key or the reply of script is nil, like redis.Nil.
+var Nil = errors.New("memory: nil")
+
+// ========== Option ==========
+type Option func(*Memory)
+
+// WithCleanInterval sets the interval of cleaning the expired keys, 0 means lazy cleaning only.
+func WithCleanInterval(t time.Duration) Option {
+	return func(o *Memory) {
+		o.cleanInterval = t
+	}
+}
+
+// ========== /Option ==========
+
+// Memory is an in-process db implementing Eval, Get and Subscribe,
+// so it can be used as limiter.IFreqDb and lock.IDistributedLockDb without redis.
+type Memory struct {
+	lock    sync.Mutex
+	data    map[string]*entry
+	state   *lua.LState
+	scripts map[string]*lua.FunctionProto
+	subs    map[string]map[chan string]struct{}
+
+	cleanInterval time.Duration
+	close         func()
+}
+
+// New returns a instance of Memory.
+func New(opts ...Option) (m *Memory) {
+	m = &Memory{
+		data:          make(map[string]*entry),
+		scripts:       make(map[string]*lua.FunctionProto),
+		cleanInterval: time.Minute,
+	}
+	for _, o := range opts {
+		o(m)
+	}
+	m.state = m.newState()
+
+	done := make(chan struct{})
+	var once sync.Once
+	m.close = func() {
+		once.Do(func() {
+			close(done)
+		})
+	}
+	if m.cleanInterval > 0 {
+		go m.clean(done)
+	}
+	return
+}
+
+// Eval evaluates the lua script like redis's EVAL.
+func (m *Memory) Eval(c context.Context, script string, keys []string, args []any) (rst any, err error) {
+	if err = c.Err(); err != nil {
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if rst, err = m.eval(script, keys, args); err == nil && rst == nil {
+		err = Nil
+	}
+	return
+}
+
+// Get returns the string value of key.
+func (m *Memory) Get(c context.Context, key string) (s string, err error) {
+	if err = c.Err(); err != nil {
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	switch rst := m.do("GET", key).(type) {
+	case string:
+		s = rst
+	case errReply:
+		err = errors.New(string(rst))
+	default:
+		err = Nil
+	}
+	return
+}
+
+// Do runs one command like redis's client, such as Do(c, "SET", "k", "v", "EX", 10).
+func (m *Memory) Do(c context.Context, args ...any) (rst any, err error) {
+	if err = c.Err(); err != nil {
+		return
+	}
+	if len(args) == 0 {
+		err = errors.New("memory: empty command")
+		return
+	}
+
+	ss := make([]string, 0, len(args))
+	for _, a := range args {
+		ss = append(ss, toString(a))
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	rst = m.do(ss[0], ss[1:]...)
+	switch v := rst.(type) {
+	case errReply:
+		rst, err = nil, errors.New(string(v))
+	case statusReply:
+		rst = string(v)
+	case nil:
+		err = Nil
+	}
+	return
+}
+
+// Close stops cleaning the expired keys.
+func (m *Memory) Close() func() {
+	return m.close
+}
+
+func (m *Memory) clean(done chan struct{}) {
+	t := time.NewTicker(m.cleanInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			m.lock.Lock()
+			now := time.Now()
+			for k, e := range m.data {
+				if e.expired(now) {
+					delete(m.data, k)
+				}
+			}
+			m.lock.Unlock()
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package memory
+
+/*
+ * @abstract in-process db evaluating the redis lua scripts
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEval(t *testing.T) {
+	m := New()
+	defer m.Close()()
+	c := context.Background()
+
+	tests := []struct {
+		name   string
+		script string
+		keys   []string
+		args   []any
+		rst    any
+		err    error
+	}{
+		{
+			name:   "integer",
+			script: `return redis.call('INCR', KEYS[1])`,
+			keys:   []string{"incr"},
+			rst:    int64(1),
+		},
+		{
+			name:   "float is truncated",
+			script: `return 3.9`,
+			rst:    int64(3),
+		},
+		{
+			name:   "status",
+			script: `return redis.call('SET', KEYS[1], ARGV[1])`,
+			keys:   []string{"str"},
+			args:   []any{"v"},
+			rst:    "OK",
+		},
+		{
+			name:   "bulk",
+			script: `return redis.call('GET', KEYS[1])`,
+			keys:   []string{"str"},
+			rst:    "v",
+		},
+		{
+			name:   "nil",
+			script: `return redis.call('GET', KEYS[1])`,
+			keys:   []string{"none"},
+			err:    Nil,
+		},
+		{
+			name:   "array",
+			script: `return {1, 'a', false, 2}`,
+			rst:    []any{int64(1), "a", nil, int64(2)},
+		},
+		{
+			name:   "array stops at nil",
+			script: `return {1, nil, 2}`,
+			rst:    []any{int64(1)},
+		},
+		{
+			name:   "set nx",
+			script: `if redis.call('SET', KEYS[1], ARGV[1], 'EX', ARGV[2], 'NX') == false then return 'fail' end return 'ok'`,
+			keys:   []string{"str"},
+			args:   []any{"v", 10},
+			rst:    "fail",
+		},
+		{
+			name:   "hash",
+			script: `redis.call('HMSET', KEYS[1], 'a', 1, 'b', 2.5) return redis.call('HMGET', KEYS[1], 'a', 'b')`,
+			keys:   []string{"hash"},
+			rst:    []any{"1", "2.5"},
+		},
+		{
+			name:   "zset",
+			script: `redis.call('ZADD', KEYS[1], 3, 'c', 1, 'a', 2, 'b') redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', '(2') return redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', '+inf', 'WITHSCORES')`,
+			keys:   []string{"zset"},
+			rst:    []any{"b", "2", "c", "3"},
+		},
+		{
+			name:   "pcall returns error",
+			script: `local r = redis.pcall('INCR', KEYS[1]) return r['err']`,
+			keys:   []string{"hash"},
+			rst:    string(errWrongType),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rst, err := m.Eval(c, tt.script, tt.keys, tt.args)
+			if err != tt.err {
+				t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, tt.err)
+			}
+			if !reflect.DeepEqual(rst, tt.rst) {
+				t.Errorf("%s has wrong %#v should %#v", t.Name(), rst, tt.rst)
+			}
+		})
+	}
+
+	if _, err := m.Eval(c, `return redis.call('INCR', KEYS[1])`, []string{"hash"}, nil); err == nil {
+		t.Errorf("%s should have err with WRONGTYPE", t.Name())
+	}
+
+	fmt.Println(t.Name())
+}
+
+func TestExpire(t *testing.T) {
+	m := New(WithCleanInterval(10 * time.Millisecond))
+	defer m.Close()()
+	c := context.Background()
+
+	if _, err := m.Do(c, "SET", "k", "v", "PX", 50); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+	}
+	if v, err := m.Get(c, "k"); err != nil || v != "v" {
+		t.Errorf("%s has wrong %s,%+v", t.Name(), v, err)
+	}
+	if ttl, err := m.Do(c, "PTTL", "k"); err != nil || ttl.(int64) <= 0 {
+		t.Errorf("%s has wrong ttl %+v,%+v", t.Name(), ttl, err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if _, err := m.Get(c, "k"); err != Nil {
+		t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, Nil)
+	}
+
+	m.lock.Lock()
+	l := len(m.data)
+	m.lock.Unlock()
+	if l != 0 {
+		t.Errorf("%s has wrong %d should %d", t.Name(), l, 0)
+	}
+
+	fmt.Println(t.Name())
+}
+
+func TestSubscribe(t *testing.T) {
+	m := New()
+	defer m.Close()()
+	c := context.Background()
+
+	msgs, cancel, err := m.Subscribe(c, "ch")
+	if err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+		return
+	}
+	if n, err := m.Eval(c, "return redis.call('PUBLISH', KEYS[1], ARGV[1])", []string{"ch"}, []any{"hi"}); err != nil || n != int64(1) {
+		t.Errorf("%s has wrong %+v,%+v", t.Name(), n, err)
+	}
+	if msg := <-msgs; msg != "hi" {
+		t.Errorf("%s has wrong %s should %s", t.Name(), msg, "hi")
+	}
+
+	cancel()
+	if _, ok := <-msgs; ok {
+		t.Errorf("%s should be closed after cancel", t.Name())
+	}
+	if n, err := m.Do(c, "PUBLISH", "ch", "hi"); err != nil || n != int64(0) {
+		t.Errorf("%s has wrong %+v,%+v", t.Name(), n, err)
+	}
+
+	ctx, done := context.WithCancel(c)
+	msgs, cancel, _ = m.Subscribe(ctx, "ch")
+	defer cancel()
+	done()
+	if _, ok := <-msgs; ok {
+		t.Errorf("%s should be closed after the context is done", t.Name())
+	}
+
+	fmt.Println(t.Name())
+}
@@ -0,0 +1,64 @@
+package memory
+
+/*
+ * @abstract the publish and subscribe of Memory
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"sync"
+)
+
+// Subscribe returns the messages published to channel until cancel is called or c is done.
+// The messages are dropped when the receiver is too slow, like a redis client with a small buffer.
+func (m *Memory) Subscribe(c context.Context, channel string) (msgs <-chan string, cancel func(), err error) {
+	if err = c.Err(); err != nil {
+		return
+	}
+
+	ch := make(chan string, 16)
+	m.lock.Lock()
+	if m.subs == nil {
+		m.subs = make(map[string]map[chan string]struct{})
+	}
+	if m.subs[channel] == nil {
+		m.subs[channel] = make(map[chan string]struct{})
+	}
+	m.subs[channel][ch] = struct{}{}
+	m.lock.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			m.lock.Lock()
+			defer m.lock.Unlock()
+			delete(m.subs[channel], ch)
+			if len(m.subs[channel]) == 0 {
+				delete(m.subs, channel)
+			}
+			close(ch)
+		})
+	}
+	stop := context.AfterFunc(c, unsubscribe)
+	cancel = func() {
+		stop()
+		unsubscribe()
+	}
+	msgs = ch
+	return
+}
+
+// args: channel message
+func cmdPublish(m *Memory, args []string) any {
+	var n int64
+	for ch := range m.subs[args[0]] {
+		select {
+		case ch <- args[1]:
+		default:
+		}
+		n++
+	}
+	return n
+}
@@ -0,0 +1,58 @@
+package orm
+
+/*
+ * @abstract rejecting the writing with a stale fencing token
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrStaleToken is returned when the fencing token is older than the one in the row.
+var ErrStaleToken = errors.New("orm: stale fencing token")
+
+// FencingScope limits the rows to those whose token in column is not newer than token.
+func FencingScope(column string, token int64) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(clause.Lte{Column: clause.Column{Name: column}, Value: token})
+	}
+}
+
+// UpdateWithToken updates values and token in column of the rows selected by db,
+// and returns ErrStaleToken when no row is updated since a newer token has written,
+// or gorm.ErrRecordNotFound when no row is selected by db.
+// The dsn of mysql should have clientFoundRows=true, or rewriting the same values is also stale.
+func UpdateWithToken(db *gorm.DB, column string, token int64, values map[string]any) (err error) {
+	vs := make(map[string]any, len(values)+1)
+	for k, v := range values {
+		vs[k] = v
+	}
+	vs[column] = token
+
+	// reusable for counting the rows without the fencing condition.
+	db = db.Session(&gorm.Session{})
+
+	rst := db.Scopes(FencingScope(column, token)).Updates(vs)
+	if err = rst.Error; err != nil {
+		return
+	}
+	if rst.RowsAffected > 0 {
+		return
+	}
+
+	var n int64
+	if err = db.Count(&n).Error; err != nil {
+		return
+	}
+	if n == 0 {
+		err = gorm.ErrRecordNotFound
+		return
+	}
+	err = ErrStaleToken
+	return
+}
@@ -2,10 +2,14 @@ module github.com/neo532/gokit/database/orm
 
 go 1.23.1
 
-replace github.com/neo532/gokit => ../..
+replace (
+	github.com/neo532/gokit => ../..
+	github.com/neo532/gokit/lock => ../../lock
+)
 
 require (
 	github.com/neo532/gokit v1.0.45
+	github.com/neo532/gokit/lock v1.0.45
 	gorm.io/driver/mysql v1.6.0
 	gorm.io/gorm v1.31.1
 )
@@ -13,6 +17,7 @@ require (
 require (
 	filippo.io/edwards25519 v1.1.0 // indirect
 	github.com/go-sql-driver/mysql v1.8.1 // indirect
+	github.com/google/uuid v1.6.0 // indirect
 	github.com/jinzhu/inflection v1.0.0 // indirect
 	github.com/jinzhu/now v1.1.5 // indirect
 	golang.org/x/text v0.20.0 // indirect
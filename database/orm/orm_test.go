@@ -12,6 +12,8 @@ import (
 	"testing"
 	"time"
 
+	"gorm.io/gorm"
+
 	"github.com/neo532/gokit/logger"
 )
 
@@ -97,3 +99,36 @@ func TestTransaction(t *testing.T) {
 
 	fmt.Println(t.Name())
 }
+
+func TestUpdateWithToken(t *testing.T) {
+
+	dbs, clean, err := initDB()
+	defer clean()
+	if err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+		return
+	}
+
+	c := context.Background()
+	db := dbs.Write(c)
+	if err = db.Exec("CREATE TEMPORARY TABLE fencing (id INT PRIMARY KEY, val VARCHAR(32), token BIGINT NOT NULL DEFAULT 0)").Error; err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+		return
+	}
+	if err = db.Exec("INSERT INTO fencing (id, val) VALUES (1, '')").Error; err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+		return
+	}
+
+	if err = UpdateWithToken(db.Table("fencing").Where("id = ?", 1), "token", 2, map[string]any{"val": "new"}); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+	}
+	if err = UpdateWithToken(db.Table("fencing").Where("id = ?", 1), "token", 1, map[string]any{"val": "zombie"}); err != ErrStaleToken {
+		t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, ErrStaleToken)
+	}
+	if err = UpdateWithToken(db.Table("fencing").Where("id = ?", 2), "token", 3, map[string]any{"val": "missing"}); err != gorm.ErrRecordNotFound {
+		t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, gorm.ErrRecordNotFound)
+	}
+
+	fmt.Println(t.Name())
+}
@@ -203,6 +203,12 @@ func (d *Orms) Transaction(c context.Context, fn func(c context.Context) (err er
 	})
 }
 
+// TransactionFromContext returns the transaction opened by Transaction in c.
+func TransactionFromContext(c context.Context) (tx *gorm.DB, ok bool) {
+	tx, ok = c.Value(contextTransactionKey{}).(*gorm.DB)
+	return
+}
+
 func (d *Orms) Close() func() {
 	return func() {
 		if d.read != nil {
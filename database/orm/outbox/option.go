@@ -0,0 +1,95 @@
+package outbox
+
+/*
+ * @abstract outbox's option
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"time"
+
+	"github.com/neo532/gokit/gofunc"
+	"github.com/neo532/gokit/logger"
+	"github.com/neo532/gokit/queue"
+)
+
+// ========== Option ==========
+type Option func(*Outbox)
+
+func WithLogger(l logger.ILogger) Option {
+	return func(o *Outbox) {
+		o.logger = l
+	}
+}
+
+// WithTable sets the name of the outbox table, the default is outbox.
+func WithTable(s string) Option {
+	return func(o *Outbox) {
+		o.table = s
+	}
+}
+
+// WithProducer relays the messages of topic through pdc.
+func WithProducer(topic string, pdc queue.Producer) Option {
+	return func(o *Outbox) {
+		o.producers[topic] = pdc
+	}
+}
+
+// WithMiddleware sets the middlewares run on enqueuing, the header set by them is stored with the message.
+func WithMiddleware(ms ...queue.ProducerMiddleware) Option {
+	return func(o *Outbox) {
+		o.middleware = append(o.middleware, ms...)
+	}
+}
+
+// WithInterval sets the interval of polling the pending messages.
+func WithInterval(t time.Duration) Option {
+	return func(o *Outbox) {
+		o.interval = t
+	}
+}
+
+// WithBatch sets the max count of the messages relayed in one polling, the non-positive n is ignored.
+func WithBatch(n int) Option {
+	return func(o *Outbox) {
+		if n > 0 {
+			o.batch = n
+		}
+	}
+}
+
+// WithEncoder sets the encoder of the message on enqueuing, json by default.
+// It should be the same as the one of the producers, as the encoded message is sent to them unchanged.
+func WithEncoder(fn func(message any) (msg []byte, err error)) Option {
+	return func(o *Outbox) {
+		o.encoder = fn
+	}
+}
+
+// WithRetry sets the retry of publishing a message in place,
+// the failed attempts are reported to the logger of r, the last one is also reported to the logger of Outbox.
+func WithRetry(r *gofunc.Retry) Option {
+	return func(o *Outbox) {
+		o.retry = r
+	}
+}
+
+// WithMaxAttempts marks the message as failed after n pollings failed to publish it, so it does not block the others.
+// 0 means retrying forever, the messages are published in order.
+func WithMaxAttempts(n int) Option {
+	return func(o *Outbox) {
+		o.maxAttempts = n
+	}
+}
+
+// WithLock relays only on the instance holding the lock of key, such as lock.DistributedLock,
+// the lock is extended every third of expire while relaying.
+func WithLock(l ILocker, key string, expire time.Duration) Option {
+	return func(o *Outbox) {
+		o.locker = l
+		o.lockKey = key
+		o.lockExpire = expire
+	}
+}
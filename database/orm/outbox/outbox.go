@@ -0,0 +1,159 @@
+package outbox
+
+/*
+ * @abstract transactional outbox publishing the messages written with the business data
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/neo532/gokit/database/orm"
+	"github.com/neo532/gokit/gofunc"
+	"github.com/neo532/gokit/logger"
+	"github.com/neo532/gokit/queue"
+)
+
+const (
+	StatusPending int8 = 0
+	StatusSent    int8 = 1
+	StatusFailed  int8 = 2
+)
+
+// HeaderOutboxID is the header of the id of the row, the consumer can deduplicate by it.
+var HeaderOutboxID = "x-outbox-id"
+
+// ErrNoTransaction is returned when enqueuing out of Orms.Transaction.
+var ErrNoTransaction = errors.New("outbox: no transaction")
+
+// Message is the row of the outbox table.
+type Message struct {
+	ID        int64      `gorm:"column:id;primaryKey;autoIncrement;index:idx_status_id,priority:2"`
+	Topic     string     `gorm:"column:topic;type:varchar(255);not null"`
+	Value     []byte     `gorm:"column:value;type:mediumblob;not null"`
+	Header    string     `gorm:"column:header;type:text"`
+	Status    int8       `gorm:"column:status;not null;default:0;index:idx_status_id,priority:1"`
+	Attempts  int        `gorm:"column:attempts;not null;default:0"`
+	LastError string     `gorm:"column:last_error;type:varchar(1024);not null;default:''"`
+	CreatedAt time.Time  `gorm:"column:created_at"`
+	SentAt    *time.Time `gorm:"column:sent_at"`
+}
+
+// Schema returns the DDL of the outbox table for mysql, Message is also for gorm's AutoMigrate.
+func Schema(table string) string {
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS `%s` ("+
+		"`id` BIGINT NOT NULL AUTO_INCREMENT,"+
+		"`topic` VARCHAR(255) NOT NULL,"+
+		"`value` MEDIUMBLOB NOT NULL,"+
+		"`header` TEXT,"+
+		"`status` TINYINT NOT NULL DEFAULT 0,"+
+		"`attempts` INT NOT NULL DEFAULT 0,"+
+		"`last_error` VARCHAR(1024) NOT NULL DEFAULT '',"+
+		"`created_at` DATETIME(3) NOT NULL,"+
+		"`sent_at` DATETIME(3) NULL,"+
+		"PRIMARY KEY (`id`),"+
+		"KEY `idx_status_id` (`status`, `id`)"+
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4", table)
+}
+
+// ILocker is the interface for the lock of relaying, such as lock.DistributedLock.
+// TryLock should return lock.ErrNotAcquired when the lock is held by others.
+type ILocker interface {
+	TryLock(c context.Context, key string, expire time.Duration) (code string, err error)
+	Extend(c context.Context, key string, code string, expire time.Duration) (err error)
+	UnLock(c context.Context, key string, code string) (err error)
+}
+
+// Outbox writes the messages in the transaction of the business data,
+// and Run relays them to the producers, so a message is published if and only if the transaction commits.
+type Outbox struct {
+	dbs         *orm.Orms
+	table       string
+	producers   map[string]queue.Producer
+	middleware  []queue.ProducerMiddleware
+	interval    time.Duration
+	batch       int
+	encoder     func(message any) (msg []byte, err error)
+	retry       *gofunc.Retry
+	maxAttempts int
+	locker      ILocker
+	lockKey     string
+	lockExpire  time.Duration
+	logger      logger.ILogger
+}
+
+// New returns a instance of Outbox.
+func New(dbs *orm.Orms, opts ...Option) (o *Outbox) {
+	o = &Outbox{
+		dbs:        dbs,
+		table:      "outbox",
+		producers:  make(map[string]queue.Producer),
+		middleware: make([]queue.ProducerMiddleware, 0, 1),
+		interval:   time.Second,
+		batch:      100,
+		encoder:    json.Marshal,
+		retry:      gofunc.NewRetry(gofunc.WithRetryLogger(gofunc.NopLogger{})),
+		logger:     logger.NewDefaultILogger(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return
+}
+
+// Enqueue writes the message of topic in the transaction opened by Orms.Transaction in c.
+func (o *Outbox) Enqueue(c context.Context, topic string, message any) (err error) {
+	tx, ok := orm.TransactionFromContext(c)
+	if !ok {
+		return ErrNoTransaction
+	}
+
+	c = queue.InitHeaderToContext(c)
+
+	ps := []any{
+		queue.KeyName, o.table,
+		queue.KeyTopic, topic,
+	}
+
+	var msg []byte
+	if msg, err = queue.Encode(message, o.encoder); err != nil {
+		ps = append(ps, queue.KeyErr, err, queue.KeyMessage, message)
+		o.logger.Error(c, "Outbox's encoder Has err!", ps...)
+		return
+	}
+	ps = append(ps, queue.KeyMessage, string(msg))
+
+	h := func(c context.Context, message any) (err error) {
+		header, _ := queue.GetHeaderFromContext(c)
+		var b []byte
+		if b, err = json.Marshal(header); err != nil {
+			return
+		}
+		m := &Message{
+			Topic:     topic,
+			Value:     msg,
+			Header:    string(b),
+			CreatedAt: time.Now(),
+		}
+		if err = tx.Table(o.table).Create(m).Error; err != nil {
+			return
+		}
+		ps = append(ps, queue.KeyOffset, m.ID)
+		return
+	}
+
+	if len(o.middleware) > 0 {
+		h = queue.ChainProducer(o.middleware...)(h)
+	}
+
+	if err = h(c, message); err != nil {
+		ps = append(ps, queue.KeyErr, err)
+		o.logger.Error(c, "Outbox's enqueuing Has err!", ps...)
+	}
+	return
+}
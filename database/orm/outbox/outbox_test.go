@@ -0,0 +1,169 @@
+package outbox
+
+/*
+ * @abstract transactional outbox
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/neo532/gokit/database/orm"
+	"github.com/neo532/gokit/lock"
+	"github.com/neo532/gokit/logger"
+	"github.com/neo532/gokit/queue"
+	"github.com/neo532/gokit/queue/memory"
+	"github.com/neo532/gokit/queue/memory/producer"
+)
+
+func initDB() (dbs *orm.Orms, clean func(), err error) {
+	dsn := "root:12345678@tcp(127.0.0.1:3306)/test?charset=utf8mb4&parseTime=true&loc=Local"
+	return orm.NewOrms(context.Background(), &orm.Config{
+		MaxOpenConns:    2,
+		MaxIdleConns:    2,
+		ConnMaxLifetime: 3 * time.Second,
+		MaxSlowtime:     3 * time.Second,
+		Write:           []*orm.DsnConfig{{Name: "default_write", Dsn: dsn}},
+	}, logger.NewDefaultILogger())
+}
+
+func TraceID() queue.ProducerMiddleware {
+	return func(handler queue.ProducerHandler) queue.ProducerHandler {
+		return func(c context.Context, message any) (err error) {
+			c = queue.AppendHeaderToContext(c, "traceID", "abc")
+			return handler(c, message)
+		}
+	}
+}
+
+func TestEnqueueNoTransaction(t *testing.T) {
+	o := New(nil)
+	if err := o.Enqueue(context.Background(), "message", "hello"); err != ErrNoTransaction {
+		t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, ErrNoTransaction)
+	}
+	fmt.Println(t.Name())
+}
+
+// errLocker fails to lock with err.
+type errLocker struct {
+	err error
+}
+
+func (l errLocker) TryLock(c context.Context, key string, expire time.Duration) (code string, err error) {
+	err = l.err
+	return
+}
+
+func (l errLocker) Extend(c context.Context, key string, code string, expire time.Duration) (err error) {
+	return
+}
+
+func (l errLocker) UnLock(c context.Context, key string, code string) (err error) {
+	return
+}
+
+func TestRelayLocked(t *testing.T) {
+	errDown := errors.New("connection refused")
+	tests := []struct {
+		name string
+		err  error
+		rst  error
+	}{
+		{name: "held by others", err: lock.ErrNotAcquired},
+		{name: "lock down", err: errDown, rst: errDown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := New(nil, WithLock(errLocker{err: tt.err}, "outbox", time.Second))
+			if n, err := o.Relay(context.Background()); n != 0 || !errors.Is(err, tt.rst) {
+				t.Errorf("%s has err[%d %+v] should [0 %+v]", t.Name(), n, err, tt.rst)
+			}
+		})
+	}
+	fmt.Println(t.Name())
+}
+
+func TestOutbox(t *testing.T) {
+	dbs, clean, err := initDB()
+	defer clean()
+	if err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+		return
+	}
+
+	c := context.Background()
+	table := "outbox_test"
+	dbs.Write(c).Exec("DROP TABLE IF EXISTS " + table)
+	if err = dbs.Write(c).Exec(Schema(table)).Error; err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+		return
+	}
+	defer dbs.Write(c).Exec("DROP TABLE IF EXISTS " + table)
+
+	b := memory.NewBroker()
+	o := New(dbs,
+		WithTable(table),
+		WithProducer("message", producer.New("default", b, producer.WithTopic("message"))),
+		WithMiddleware(TraceID()),
+	)
+
+	// rollback
+	err = dbs.Transaction(c, func(c context.Context) (err error) {
+		if err = o.Enqueue(c, "message", map[string]int{"order": 1}); err != nil {
+			return
+		}
+		return errors.New("biz error")
+	})
+	if err == nil {
+		t.Errorf("%s has err[nil] should [biz error]", t.Name())
+	}
+
+	// commit
+	if err = dbs.Transaction(c, func(c context.Context) (err error) {
+		return o.Enqueue(c, "message", map[string]int{"order": 2})
+	}); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+		return
+	}
+
+	if n, err := o.Relay(c); n != 1 || err != nil {
+		t.Errorf("%s has err[%d %+v] should [1 nil]", t.Name(), n, err)
+	}
+	if n, _ := o.Relay(c); n != 0 {
+		t.Errorf("%s has err[%d] should [0]", t.Name(), n)
+	}
+
+	ms := b.Messages("message")
+	if len(ms) != 1 {
+		t.Errorf("%s has err[%d] should [1]", t.Name(), len(ms))
+		return
+	}
+	if string(ms[0].Value) != `{"order":2}` || ms[0].Header.Value("traceID") != "abc" || ms[0].Header.Value(HeaderOutboxID) == "" {
+		t.Errorf("%s has err[%s %+v]", t.Name(), ms[0].Value, ms[0].Header)
+	}
+
+	// the one without producer is failed at once and does not block the others.
+	if err = dbs.Transaction(c, func(c context.Context) (err error) {
+		if err = o.Enqueue(c, "unknown", "hello"); err != nil {
+			return
+		}
+		return o.Enqueue(c, "message", map[string]int{"order": 3})
+	}); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+		return
+	}
+	if n, err := o.Relay(c); n != 1 || err != nil {
+		t.Errorf("%s has err[%d %+v] should [1 nil]", t.Name(), n, err)
+	}
+	var failed int64
+	dbs.Write(c).Table(table).Where("status = ?", StatusFailed).Count(&failed)
+	if failed != 1 {
+		t.Errorf("%s has err[%d] should [1]", t.Name(), failed)
+	}
+	fmt.Println(t.Name())
+}
@@ -0,0 +1,194 @@
+package outbox
+
+/*
+ * @abstract relay publishing the pending messages of outbox
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/neo532/gokit/lock"
+	"github.com/neo532/gokit/queue"
+)
+
+// Run relays the pending messages every interval until c is done.
+func (o *Outbox) Run(c context.Context) (err error) {
+	t := time.NewTicker(o.interval)
+	defer t.Stop()
+	for {
+		// a full batch means more pending messages.
+		n, e := o.Relay(c)
+		if e == nil && n >= o.batch && c.Err() == nil {
+			continue
+		}
+		select {
+		case <-c.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Relay publishes the pending messages in order once and returns the count of the sent ones.
+// It stops at the failed message to keep the order, unless it has failed for maxAttempts
+// or it never succeeds, such as the one of the topic without producer.
+// The lock is extended every third of the expire while relaying, and it stops once the lock is lost.
+func (o *Outbox) Relay(c context.Context) (n int, err error) {
+	if o.locker != nil {
+		var code string
+		if code, err = o.locker.TryLock(c, o.lockKey, o.lockExpire); err != nil {
+			if errors.Is(err, lock.ErrNotAcquired) {
+				// held by the other instance.
+				o.logger.Debug(c, "Outbox's relay is locked!", queue.KeyName, o.table)
+				err = nil
+				return
+			}
+			o.logger.Error(c, "Outbox's locking Has err!", queue.KeyName, o.table, queue.KeyErr, err)
+			return
+		}
+
+		var lost context.CancelCauseFunc
+		c, lost = context.WithCancelCause(c)
+		done := o.extend(c, code, lost)
+		defer func() {
+			lost(nil)
+			<-done
+			o.locker.UnLock(context.WithoutCancel(c), o.lockKey, code)
+		}()
+	}
+
+	// the primary, the replica may lag behind.
+	db := o.dbs.Write(c)
+
+	var ms []Message
+	if err = db.Table(o.table).
+		Where("status = ?", StatusPending).
+		Order("id").
+		Limit(o.batch).
+		Find(&ms).Error; err != nil {
+		o.logger.Error(c, "Outbox's polling Has err!", queue.KeyName, o.table, queue.KeyErr, err)
+		return
+	}
+
+	for _, m := range ms {
+		if c.Err() != nil {
+			err = context.Cause(c)
+			return
+		}
+
+		ps := []any{
+			queue.KeyName, o.table,
+			queue.KeyTopic, m.Topic,
+			queue.KeyOffset, m.ID,
+			queue.KeyMessage, string(m.Value),
+		}
+
+		var permanent bool
+		if permanent, err = o.publish(c, m); err != nil {
+			ps = append(ps, queue.KeyErr, err)
+			o.logger.Error(c, "Outbox's publishing Has err!", ps...)
+
+			failed := permanent || (o.maxAttempts > 0 && m.Attempts+1 >= o.maxAttempts)
+			if e := o.fail(db, m, err, failed); e != nil {
+				o.logger.Error(c, "Outbox's marking Has err!", append(ps, "markErr", e)...)
+			}
+			if failed {
+				err = nil
+				continue
+			}
+			return
+		}
+
+		if err = db.Table(o.table).
+			Where("id = ? AND status = ?", m.ID, StatusPending).
+			Updates(map[string]any{"status": StatusSent, "sent_at": time.Now()}).Error; err != nil {
+			// published but not marked, it will be published again.
+			ps = append(ps, queue.KeyErr, err)
+			o.logger.Error(c, "Outbox's marking Has err!", ps...)
+			return
+		}
+		n++
+		o.logger.Info(c, "Outbox have been relayed!", ps...)
+	}
+	return
+}
+
+// publish sends the encoded message unchanged with its header and HeaderOutboxID with retries,
+// permanent is true if it never succeeds.
+func (o *Outbox) publish(c context.Context, m Message) (permanent bool, err error) {
+	pdc, ok := o.producers[m.Topic]
+	if !ok {
+		permanent = true
+		err = fmt.Errorf("outbox: no producer of topic %s", m.Topic)
+		return
+	}
+
+	header := make(queue.Header)
+	if m.Header != "" {
+		if err = json.Unmarshal([]byte(m.Header), &header); err != nil {
+			permanent = true
+			return
+		}
+	}
+	header.Set(HeaderOutboxID, strconv.FormatInt(m.ID, 10))
+
+	err = o.retry.Do(c, func(c context.Context) error {
+		return pdc.Send(queue.WithForwardHeader(c, header), queue.Encoded(m.Value))
+	})
+	return
+}
+
+// extend extends the lock every third of the expire until c is done,
+// it calls lost with the error once the lock is lost, and done is closed when it returns.
+func (o *Outbox) extend(c context.Context, code string, lost context.CancelCauseFunc) (done chan struct{}) {
+	done = make(chan struct{})
+	interval := o.lockExpire / 3
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+	go func() {
+		defer close(done)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-c.Done():
+				return
+			case <-t.C:
+			}
+			if err := o.locker.Extend(c, o.lockKey, code, o.lockExpire); err != nil {
+				if c.Err() == nil {
+					o.logger.Error(c, "Outbox's lock is lost!", queue.KeyName, o.table, queue.KeyErr, err)
+					lost(err)
+				}
+				return
+			}
+		}
+	}()
+	return
+}
+
+// fail records the error of the message, and marks it as failed if failed is true.
+func (o *Outbox) fail(db *gorm.DB, m Message, err error, failed bool) error {
+	s := err.Error()
+	if len(s) > 1024 {
+		s = s[:1024]
+	}
+	vs := map[string]any{
+		"attempts":   gorm.Expr("attempts + 1"),
+		"last_error": s,
+	}
+	if failed {
+		vs["status"] = StatusFailed
+	}
+	return db.Table(o.table).Where("id = ? AND status = ?", m.ID, StatusPending).Updates(vs).Error
+}
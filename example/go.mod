@@ -2,11 +2,15 @@ module github.com/neo532/gokit/example
 
 go 1.23.1
 
-replace github.com/neo532/gokit => ..
+replace (
+	github.com/neo532/gokit => ..
+	github.com/neo532/gokit/database/memory => ../database/memory
+)
 
 require (
 	github.com/go-redis/redis/v8 v8.11.5
 	github.com/neo532/gokit v1.0.45
+	github.com/neo532/gokit/database/memory v1.0.45
 	github.com/stretchr/testify v1.8.4
 )
 
@@ -15,5 +19,6 @@ require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -59,6 +59,49 @@ func TestFreq(t *testing.T) {
 	fmt.Println(t.Name())
 }
 
+func TestFreqSliding(t *testing.T) {
+	c := context.Background()
+	freq := limiter.NewFreq(newRDB())
+
+	dstTimes := 3
+	for _, mode := range []string{limiter.ModeSlidingLog, limiter.ModeSlidingCounter} {
+		preKey := "user.test.sliding." + mode
+		rule := []limiter.FreqRule{
+			{Duri: "5", Times: int64(dstTimes), Mode: mode},
+		}
+
+		count := 100
+		var wg sync.WaitGroup
+		wg.Add(count)
+		var lock sync.Mutex
+		num := 0
+		for i := 0; i < count; i++ {
+			go func() {
+				defer wg.Done()
+				if ok, err := freq.IncrCheck(c, preKey, rule...); err == nil && ok {
+					lock.Lock()
+					num++
+					lock.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		if dstTimes != num {
+			t.Errorf("%s[%s] has wrong %d should %d", t.Name(), mode, num, dstTimes)
+		}
+
+		times, err := freq.Get(c, preKey, rule...)
+		if err != nil {
+			t.Errorf("%s[%s] has error[%+v]", t.Name(), mode, err)
+		}
+		if dst := int64(dstTimes); dst != times {
+			t.Errorf("%s[%s] has wrong %d should %d", t.Name(), mode, times, dst)
+		}
+	}
+
+	fmt.Println(t.Name())
+}
+
 type rDB struct {
 	cache *redis.Client
 }
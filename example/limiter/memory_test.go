@@ -0,0 +1,99 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/neo532/gokit/database/memory"
+	"github.com/neo532/gokit/limiter"
+)
+
+func TestFreqMemory(t *testing.T) {
+	db := memory.New()
+	defer db.Close()()
+
+	c := context.Background()
+	freq := limiter.NewFreq(db)
+
+	dstTimes := 3
+	for _, rule := range []limiter.FreqRule{
+		{Duri: "10", Times: int64(dstTimes)},
+		{Duri: limiter.DurationToday, Times: int64(dstTimes)},
+		{Duri: "10", Times: int64(dstTimes), Mode: limiter.ModeSlidingLog},
+		{Duri: "10", Times: int64(dstTimes), Mode: limiter.ModeSlidingCounter},
+	} {
+		preKey := "user.test.memory." + rule.Mode
+		num := 0
+		for i := 0; i < 10; i++ {
+			if ok, err := freq.IncrCheck(c, preKey, rule); err == nil && ok {
+				num++
+			}
+		}
+		if dstTimes != num {
+			t.Errorf("%s[%s] has wrong %d should %d", t.Name(), rule.Duri+rule.Mode, num, dstTimes)
+		}
+
+		if ok, err := freq.Check(c, preKey, rule); err != nil || ok {
+			t.Errorf("%s[%s] has wrong %v,%+v", t.Name(), rule.Duri+rule.Mode, ok, err)
+		}
+	}
+
+	rate := limiter.NewRate(db)
+	if ok, _, err := rate.Allow(c, "user.test.memory", limiter.PerSecond(1, 1)); err != nil || !ok {
+		t.Errorf("%s has wrong %v,%+v", t.Name(), ok, err)
+	}
+	if ok, retryAfter, err := rate.Allow(c, "user.test.memory", limiter.PerSecond(1, 1)); err != nil || ok || retryAfter <= 0 {
+		t.Errorf("%s has wrong %v,%v,%+v", t.Name(), ok, retryAfter, err)
+	}
+
+	fmt.Println(t.Name())
+}
+
+func TestFreqAtomicMemory(t *testing.T) {
+	db := memory.New()
+	defer db.Close()()
+
+	c := context.Background()
+	freq := limiter.NewFreq(db)
+
+	preKey := "user.test.atomic"
+	minute := limiter.FreqRule{Duri: "60", Times: 10}
+	rule := []limiter.FreqRule{
+		minute,
+		{Duri: limiter.DurationToday, Times: 2},
+		{Duri: "60", Times: 10, Mode: limiter.ModeSlidingLog},
+		{Duri: "60", Times: 10, Mode: limiter.ModeSlidingCounter},
+	}
+
+	for i := 0; i < 5; i++ {
+		freq.IncrCheck(c, preKey, rule...)
+	}
+	for _, r := range []limiter.FreqRule{minute, rule[2], rule[3]} {
+		if times, err := freq.Get(c, preKey, r); err != nil || times != 2 {
+			t.Errorf("%s[%s] has wrong %d should %d,%+v", t.Name(), r.Mode, times, 2, err)
+		}
+	}
+
+	// refund
+	if ok, err := freq.Decr(c, preKey, rule...); err != nil || !ok {
+		t.Errorf("%s has wrong %v,%+v", t.Name(), ok, err)
+	}
+	if ok, err := freq.IncrCheck(c, preKey, rule...); err != nil || !ok {
+		t.Errorf("%s has wrong %v,%+v", t.Name(), ok, err)
+	}
+	for _, r := range []limiter.FreqRule{minute, rule[2], rule[3]} {
+		if times, err := freq.Get(c, preKey, r); err != nil || times != 2 {
+			t.Errorf("%s[%s] has wrong %d should %d,%+v", t.Name(), r.Mode, times, 2, err)
+		}
+	}
+
+	if err := freq.Reset(c, preKey, rule...); err != nil {
+		t.Errorf("%s has error[%+v]", t.Name(), err)
+	}
+	if ok, err := freq.IncrCheck(c, preKey, rule...); err != nil || !ok {
+		t.Errorf("%s has wrong %v,%+v", t.Name(), ok, err)
+	}
+
+	fmt.Println(t.Name())
+}
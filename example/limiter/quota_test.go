@@ -0,0 +1,83 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/neo532/gokit/database/memory"
+	"github.com/neo532/gokit/limiter"
+)
+
+func TestFreqQuotaMemory(t *testing.T) {
+	db := memory.New()
+	defer db.Close()()
+
+	c := context.Background()
+	freq := limiter.NewFreq(db)
+
+	preKey := "user.test.quota"
+	rule := []limiter.FreqRule{
+		{Duri: "60", Times: 3},
+		{Duri: limiter.DurationToday, Times: 10},
+		{Duri: "60", Times: 5, Mode: limiter.ModeSlidingLog},
+	}
+	for i := 0; i < 2; i++ {
+		freq.IncrCheck(c, preKey, rule...)
+	}
+
+	qs, err := freq.Quota(c, preKey, rule...)
+	if err != nil {
+		t.Errorf("%s has error[%+v]", t.Name(), err)
+		return
+	}
+	for i, dst := range []int64{1, 8, 3} {
+		q := qs[i]
+		if q.Used != 2 || q.Remaining != dst || q.Limit != rule[i].Times {
+			t.Errorf("%s has wrong %+v should remain %d", t.Name(), q, dst)
+		}
+		if q.Reset <= 0 || q.Reset > 24*time.Hour {
+			t.Errorf("%s has wrong reset %v", t.Name(), q.Reset)
+		}
+	}
+
+	h := limiter.QuotaHeader(qs...)
+	if v := h.Get("X-RateLimit-Remaining"); v != "1" {
+		t.Errorf("%s has wrong %s should %s", t.Name(), v, "1")
+	}
+	if v := h.Get("RateLimit-Limit"); v != "3" {
+		t.Errorf("%s has wrong %s should %s", t.Name(), v, "3")
+	}
+	if v := h.Get("RateLimit-Reset"); v == "" || v == "0" {
+		t.Errorf("%s has wrong reset %s", t.Name(), v)
+	}
+
+	fmt.Println(t.Name())
+}
+
+func TestFreqQuotaSlidingLogMemory(t *testing.T) {
+	db := memory.New()
+	defer db.Close()()
+
+	c := context.Background()
+	freq := limiter.NewFreq(db)
+
+	preKey := "user.test.quota.sliding"
+	rule := limiter.FreqRule{Duri: "1", Times: 2, Mode: limiter.ModeSlidingLog}
+	freq.IncrCheck(c, preKey, rule)
+	time.Sleep(300 * time.Millisecond)
+	freq.IncrCheck(c, preKey, rule)
+
+	// the oldest hit leaves the window first, not a window after the newest one.
+	qs, err := freq.Quota(c, preKey, rule)
+	if err != nil {
+		t.Errorf("%s has error[%+v]", t.Name(), err)
+		return
+	}
+	if q := qs[0]; q.Remaining != 0 || q.Reset <= 0 || q.Reset > 800*time.Millisecond {
+		t.Errorf("%s has wrong %+v", t.Name(), q)
+	}
+
+	fmt.Println(t.Name())
+}
@@ -0,0 +1,53 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/neo532/gokit/limiter"
+)
+
+func TestRate(t *testing.T) {
+	c := context.Background()
+
+	burst := 5
+	for _, algorithm := range []string{limiter.AlgorithmGCRA, limiter.AlgorithmTokenBucket} {
+		rate := limiter.NewRate(newRDB()).Algorithm(algorithm)
+		key := "user.test.rate." + algorithm
+		limit := limiter.PerMinute(60, int64(burst))
+
+		count := 100
+		var wg sync.WaitGroup
+		wg.Add(count)
+		var lock sync.Mutex
+		num := 0
+		for i := 0; i < count; i++ {
+			go func() {
+				defer wg.Done()
+				if ok, _, err := rate.Allow(c, key, limit); err == nil && ok {
+					lock.Lock()
+					num++
+					lock.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		if burst != num {
+			t.Errorf("%s[%s] has wrong %d should %d", t.Name(), algorithm, num, burst)
+		}
+
+		ok, retryAfter, err := rate.Allow(c, key, limit)
+		if err != nil || ok || retryAfter <= 0 {
+			t.Errorf("%s[%s] has wrong %v,%v,%+v", t.Name(), algorithm, ok, retryAfter, err)
+		}
+
+		delay, err := rate.Reserve(c, key, limit, 1)
+		if err != nil || delay <= 0 {
+			t.Errorf("%s[%s] has wrong %v,%+v", t.Name(), algorithm, delay, err)
+		}
+	}
+
+	fmt.Println(t.Name())
+}
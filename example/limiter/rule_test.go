@@ -0,0 +1,97 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/neo532/gokit/database/memory"
+	"github.com/neo532/gokit/limiter"
+)
+
+func TestParseFreqRules(t *testing.T) {
+	shanghai, _ := time.LoadLocation("Asia/Shanghai")
+
+	tests := []struct {
+		name  string
+		rule  string
+		rules []limiter.FreqRule
+		err   bool
+	}{
+		{
+			name: "seconds and calendar",
+			rule: "5/1m, 100/today@Asia/Shanghai",
+			rules: []limiter.FreqRule{
+				{Duri: "60", Times: 5},
+				{Duri: limiter.DurationToday, Times: 100, Timezone: shanghai},
+			},
+		},
+		{
+			name: "days and mode",
+			rule: "10/30~slidingLog,1000/7d,3/1h~slidingCounter,1/thisYear",
+			rules: []limiter.FreqRule{
+				{Duri: "30", Times: 10, Mode: limiter.ModeSlidingLog},
+				{Duri: "604800", Times: 1000},
+				{Duri: "3600", Times: 3, Mode: limiter.ModeSlidingCounter},
+				{Duri: limiter.DurationThisYear, Times: 1},
+			},
+		},
+		{name: "no times", rule: "today", err: true},
+		{name: "wrong times", rule: "a/today", err: true},
+		{name: "wrong duration", rule: "1/tomorrow", err: true},
+		{name: "less than 1s", rule: "1/500ms", err: true},
+		{name: "wrong timezone", rule: "1/today@Mars/Base", err: true},
+		{name: "sliding calendar", rule: "1/today~slidingLog", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := limiter.ParseFreqRules(tt.rule)
+			if (err != nil) != tt.err {
+				t.Errorf("%s has err[%+v]", t.Name(), err)
+				return
+			}
+			if len(rules) != len(tt.rules) {
+				t.Errorf("%s has wrong %d should %d", t.Name(), len(rules), len(tt.rules))
+				return
+			}
+			for i, r := range rules {
+				dst := tt.rules[i]
+				if r.Duri != dst.Duri || r.Times != dst.Times || r.Mode != dst.Mode ||
+					(dst.Timezone != nil && r.Timezone.String() != dst.Timezone.String()) {
+					t.Errorf("%s has wrong %+v should %+v", t.Name(), r, dst)
+				}
+			}
+		})
+	}
+
+	fmt.Println(t.Name())
+}
+
+func TestFreqCalendarMemory(t *testing.T) {
+	db := memory.New()
+	defer db.Close()()
+
+	c := context.Background()
+	freq := limiter.NewFreq(db)
+
+	for _, duri := range []string{
+		limiter.DurationThisMinute,
+		limiter.DurationThisHour,
+		limiter.DurationToday,
+		limiter.DurationThisWeek,
+		limiter.DurationThisMonth,
+		limiter.DurationThisYear,
+	} {
+		rule := limiter.FreqRule{Duri: duri, Times: 1}
+		if ok, err := freq.IncrCheck(c, "user.test.calendar", rule); err != nil || !ok {
+			t.Errorf("%s[%s] has wrong %v,%+v", t.Name(), duri, ok, err)
+		}
+		if ok, err := freq.IncrCheck(c, "user.test.calendar", rule); err != nil || ok {
+			t.Errorf("%s[%s] has wrong %v,%+v", t.Name(), duri, ok, err)
+		}
+	}
+
+	fmt.Println(t.Name())
+}
@@ -0,0 +1,58 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/neo532/gokit/database/memory"
+	"github.com/neo532/gokit/lock"
+)
+
+func TestFencingTokenMemory(t *testing.T) {
+	db := memory.New()
+	defer db.Close()()
+
+	l := lock.NewDistributedLock(db)
+	c := context.Background()
+	key := "IamAKey"
+
+	// the first holder is paused until the lock expired.
+	_, zombie, err := l.LockWithToken(c, key, 20*time.Millisecond, time.Millisecond)
+	if err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+		return
+	}
+	if _, _, err = l.LockWithToken(c, key, 10*time.Second, time.Millisecond); err == nil {
+		t.Errorf("%s should not lock twice", t.Name())
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	code, token, err := l.LockWithToken(c, key, 10*time.Second, time.Millisecond)
+	if err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+		return
+	}
+	if token <= zombie {
+		t.Errorf("%s has wrong token %d should > %d", t.Name(), token, zombie)
+	}
+
+	resource := "order:1"
+	if err = l.CheckToken(c, resource, token); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+	}
+	if err = l.CheckToken(c, resource, token); err != nil {
+		t.Errorf("%s has err[%+v] with the same token", t.Name(), err)
+	}
+	if err = l.CheckToken(c, resource, zombie); !errors.Is(err, lock.ErrStaleToken) {
+		t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, lock.ErrStaleToken)
+	}
+
+	if err = l.UnLock(c, key, code); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+	}
+
+	fmt.Println(t.Name())
+}
@@ -0,0 +1,153 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/neo532/gokit/database/memory"
+	"github.com/neo532/gokit/lock"
+)
+
+func TestLeaderMemory(t *testing.T) {
+	db := memory.New()
+	defer db.Close()()
+
+	l := lock.NewDistributedLock(db).Duration(time.Millisecond).Notify(true)
+	key := "IamALeader"
+
+	var elected, revoked atomic.Int32
+	newLeader := func() *lock.Leader {
+		return lock.NewLeader(l, key, 10*time.Second).
+			Interval(10 * time.Millisecond).
+			OnElected(func() { elected.Add(1) }).
+			OnRevoked(func() { revoked.Add(1) })
+	}
+	leaders := []*lock.Leader{newLeader(), newLeader()}
+	cancels := make([]context.CancelFunc, len(leaders))
+
+	var running atomic.Int32
+	var wg sync.WaitGroup
+	wg.Add(len(leaders))
+	for i, e := range leaders {
+		var c context.Context
+		c, cancels[i] = context.WithCancel(context.Background())
+		go func(e *lock.Leader) {
+			defer wg.Done()
+			e.Run(c, func(c context.Context) error {
+				if running.Add(1) > 1 {
+					t.Errorf("%s has two leaders", t.Name())
+				}
+				<-c.Done()
+				running.Add(-1)
+				return nil
+			})
+		}(e)
+	}
+	defer func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+		wg.Wait()
+	}()
+
+	leader := waitLeader(leaders, time.Second)
+	if leader < 0 {
+		t.Errorf("%s has no leader", t.Name())
+		return
+	}
+
+	// graceful shutdown hands over quickly, long before the lease is expired.
+	cancels[leader]()
+	next := waitLeader(leaders[1-leader:2-leader], time.Second)
+	if next < 0 {
+		t.Errorf("%s is not handed over", t.Name())
+		return
+	}
+	if elected.Load() != 2 || revoked.Load() != 1 {
+		t.Errorf("%s has wrong elected %d, revoked %d", t.Name(), elected.Load(), revoked.Load())
+	}
+
+	fmt.Println(t.Name())
+}
+
+func TestLeaderLostMemory(t *testing.T) {
+	db := memory.New()
+	defer db.Close()()
+
+	l := lock.NewDistributedLock(db).Duration(time.Millisecond)
+	c, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	canceled := make(chan struct{}, 1)
+	e := lock.NewLeader(l, "IamALeader", 30*time.Millisecond).Interval(10 * time.Millisecond)
+	go e.Run(c, func(c context.Context) error {
+		<-c.Done()
+		canceled <- struct{}{}
+		return nil
+	})
+	if waitLeader([]*lock.Leader{e}, time.Second) < 0 {
+		t.Errorf("%s has no leader", t.Name())
+		return
+	}
+
+	// the lock is taken away.
+	db.Do(context.Background(), "SET", "lock:IamALeader", "others")
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Errorf("%s is not canceled after lost", t.Name())
+	}
+	if e.IsLeader() {
+		t.Errorf("%s should not be the leader", t.Name())
+	}
+
+	fmt.Println(t.Name())
+}
+
+func TestLeaderRunErrMemory(t *testing.T) {
+	db := memory.New()
+	defer db.Close()()
+
+	l := lock.NewDistributedLock(db).Duration(time.Millisecond)
+	c, cancel := context.WithCancel(context.Background())
+
+	errStop := errors.New("stop")
+	rst := make(chan error, 1)
+	e := lock.NewLeader(l, "IamALeader", 10*time.Second).Interval(10 * time.Millisecond)
+	go func() {
+		rst <- e.Run(c, func(c context.Context) error {
+			<-c.Done()
+			return errStop
+		})
+	}()
+	if waitLeader([]*lock.Leader{e}, time.Second) < 0 {
+		t.Errorf("%s has no leader", t.Name())
+		cancel()
+		return
+	}
+
+	// the error of fn is returned when c is done.
+	cancel()
+	if err := <-rst; !errors.Is(err, errStop) {
+		t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, errStop)
+	}
+
+	fmt.Println(t.Name())
+}
+
+// waitLeader returns the index of the leader or -1 after timeout.
+func waitLeader(leaders []*lock.Leader, timeout time.Duration) int {
+	for end := time.Now().Add(timeout); time.Now().Before(end); time.Sleep(time.Millisecond) {
+		for i, e := range leaders {
+			if e.IsLeader() {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,51 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neo532/gokit/database/memory"
+	"github.com/neo532/gokit/lock"
+)
+
+func TestDistributedLockMemory(t *testing.T) {
+	db := memory.New()
+	defer db.Close()()
+
+	l := lock.NewDistributedLock(db)
+	c := context.Background()
+	key := "IamAKey"
+
+	count := 100
+	var wg sync.WaitGroup
+	wg.Add(count)
+	var mu sync.Mutex
+	codes := make([]string, 0, 1)
+	for i := 0; i < count; i++ {
+		go func() {
+			defer wg.Done()
+			if code, err := l.Lock(c, key, 10*time.Second, 100*time.Millisecond); err == nil {
+				mu.Lock()
+				codes = append(codes, code)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(codes) != 1 {
+		t.Errorf("%s has wrong %d should %d", t.Name(), len(codes), 1)
+		return
+	}
+	if err := l.UnLock(c, key, "IamNotTheOwner"); err == nil {
+		t.Errorf("%s should have err with wrong code", t.Name())
+	}
+	if err := l.UnLock(c, key, codes[0]); err != nil {
+		t.Errorf("%s has error[%+v]", t.Name(), err)
+	}
+
+	fmt.Println(t.Name())
+}
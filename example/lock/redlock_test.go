@@ -0,0 +1,143 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/neo532/gokit/database/memory"
+	"github.com/neo532/gokit/lock"
+)
+
+// downDb is an unavailable instance.
+type downDb struct{}
+
+func (downDb) Eval(c context.Context, cmd string, keys []string, args []any) (rst any, err error) {
+	err = errors.New("connection refused")
+	return
+}
+
+func TestRedlockMemory(t *testing.T) {
+	c := context.Background()
+	key := "IamAKey"
+
+	tests := []struct {
+		name string
+		down int
+		err  bool
+	}{
+		{name: "all up", down: 0},
+		{name: "minority down", down: 2},
+		{name: "majority down", down: 3, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mems := make([]*memory.Memory, 0, 5)
+			dbs := make([]lock.IDistributedLockDb, 0, 5)
+			for i := 0; i < 5; i++ {
+				if i < tt.down {
+					dbs = append(dbs, downDb{})
+					continue
+				}
+				m := memory.New()
+				defer m.Close()()
+				mems = append(mems, m)
+				dbs = append(dbs, m)
+			}
+			l := lock.NewRedlock(dbs...).Duration(10 * time.Millisecond)
+
+			code, validity, err := l.Lock(c, key, 10*time.Second, 30*time.Millisecond)
+			if (err != nil) != tt.err {
+				t.Errorf("%s has err[%+v]", t.Name(), err)
+				return
+			}
+			if tt.err {
+				// the minority acquired should be released.
+				for _, m := range mems {
+					if _, e := m.Get(c, "lock:"+key); !errors.Is(e, memory.Nil) {
+						t.Errorf("%s has unreleased key, err[%+v]", t.Name(), e)
+					}
+				}
+				return
+			}
+			if validity <= 0 || validity > 10*time.Second {
+				t.Errorf("%s has wrong validity %v", t.Name(), validity)
+			}
+
+			if _, _, err = l.Lock(c, key, 10*time.Second, 30*time.Millisecond); err == nil {
+				t.Errorf("%s should not lock twice", t.Name())
+			}
+			if err = l.UnLock(c, key, "IamNotTheOwner"); err == nil {
+				t.Errorf("%s should have err with wrong code", t.Name())
+			}
+			if err = l.UnLock(c, key, code); err != nil {
+				t.Errorf("%s has err[%+v]", t.Name(), err)
+			}
+			for _, m := range mems {
+				if _, e := m.Get(c, "lock:"+key); !errors.Is(e, memory.Nil) {
+					t.Errorf("%s has unreleased key, err[%+v]", t.Name(), e)
+				}
+			}
+		})
+	}
+
+	if _, _, err := lock.NewRedlock().Lock(c, key, 10*time.Second, 0); !errors.Is(err, lock.ErrNoInstance) {
+		t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, lock.ErrNoInstance)
+	}
+
+	fmt.Println(t.Name())
+}
+
+func TestRedlockSplitMemory(t *testing.T) {
+	c := context.Background()
+	key := "IamAKey"
+
+	dbs := make([]lock.IDistributedLockDb, 0, 5)
+	for i := 0; i < 5; i++ {
+		m := memory.New()
+		defer m.Close()()
+		dbs = append(dbs, m)
+	}
+
+	// another holder has 2 of 5 instances, the rest 3 are still the majority.
+	other := lock.NewDistributedLock(dbs[0])
+	if _, err := other.Lock(c, key, 10*time.Second, time.Millisecond); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+		return
+	}
+	other = lock.NewDistributedLock(dbs[1])
+	if _, err := other.Lock(c, key, 10*time.Second, time.Millisecond); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+		return
+	}
+	l := lock.NewRedlock(dbs...)
+	code, _, err := l.Lock(c, key, 10*time.Second, 0)
+	if err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+		return
+	}
+	if err = l.UnLock(c, key, code); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+	}
+
+	// another holder has 3 of 5 instances.
+	other = lock.NewDistributedLock(dbs[2])
+	if _, err = other.Lock(c, key, 10*time.Second, time.Millisecond); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+		return
+	}
+	if _, _, err = l.Lock(c, key, 10*time.Second, 0); err == nil {
+		t.Errorf("%s should not lock without the majority", t.Name())
+	}
+
+	// the expire is too short to be valid after the drift.
+	l = lock.NewRedlock(dbs[3:]...).DriftFactor(1)
+	if _, _, err = l.Lock(c, "IamAnotherKey", time.Second, 0); err == nil {
+		t.Errorf("%s should not lock without validity", t.Name())
+	}
+
+	fmt.Println(t.Name())
+}
@@ -0,0 +1,191 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/neo532/gokit/database/memory"
+	"github.com/neo532/gokit/lock"
+)
+
+func TestReentrantLockMemory(t *testing.T) {
+	db := memory.New()
+	defer db.Close()()
+
+	l := lock.NewReentrantLock(db).Duration(time.Millisecond)
+	c := context.Background()
+	key := "IamAKey"
+	expire := 10 * time.Second
+	wait := 10 * time.Millisecond
+
+	code, err := l.Lock(c, key, "", expire, wait)
+	if err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+		return
+	}
+	if _, err = l.Lock(c, key, code, expire, wait); err != nil {
+		t.Errorf("%s has err[%+v] when reentering", t.Name(), err)
+	}
+	if _, err = l.Lock(c, key, "", expire, wait); err == nil {
+		t.Errorf("%s should not be locked by others", t.Name())
+	}
+	if err = l.UnLock(c, key, "IamNotTheOwner"); err == nil {
+		t.Errorf("%s should have err with wrong code", t.Name())
+	}
+
+	// held twice, unlocked once.
+	if err = l.UnLock(c, key, code); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+	}
+	if _, err = l.Lock(c, key, "", expire, wait); err == nil {
+		t.Errorf("%s should not be locked by others", t.Name())
+	}
+	if err = l.UnLock(c, key, code); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+	}
+	if err = l.UnLock(c, key, code); err == nil {
+		t.Errorf("%s should have err after released", t.Name())
+	}
+	if _, err = l.Lock(c, key, "", expire, wait); err != nil {
+		t.Errorf("%s has err[%+v] after released", t.Name(), err)
+	}
+
+	fmt.Println(t.Name())
+}
+
+func TestReadWriteLockMemory(t *testing.T) {
+	db := memory.New()
+	defer db.Close()()
+
+	l := lock.NewReadWriteLock(db).Duration(time.Millisecond)
+	c := context.Background()
+	key := "IamAKey"
+	expire := 10 * time.Second
+	wait := 10 * time.Millisecond
+
+	r1, err := l.RLock(c, key, expire, wait)
+	if err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+		return
+	}
+	r2, err := l.RLock(c, key, expire, wait)
+	if err != nil {
+		t.Errorf("%s has err[%+v] with many readers", t.Name(), err)
+		return
+	}
+	if _, err = l.Lock(c, key, expire, wait); err == nil {
+		t.Errorf("%s should not be written while reading", t.Name())
+	}
+	if err = l.RUnLock(c, key, r1); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+	}
+	if _, err = l.Lock(c, key, expire, wait); err == nil {
+		t.Errorf("%s should not be written while reading", t.Name())
+	}
+	if err = l.RUnLock(c, key, r2); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+	}
+
+	w, err := l.Lock(c, key, expire, wait)
+	if err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+		return
+	}
+	if _, err = l.RLock(c, key, expire, wait); err == nil {
+		t.Errorf("%s should not be read while writing", t.Name())
+	}
+	if _, err = l.Lock(c, key, expire, wait); err == nil {
+		t.Errorf("%s should not be written while writing", t.Name())
+	}
+	if err = l.UnLock(c, key, w); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+	}
+
+	// the waiting writer keeps the new readers out.
+	r1, _ = l.RLock(c, key, expire, wait)
+	written := make(chan error, 1)
+	go func() {
+		w, err := l.Lock(c, key, expire, time.Second)
+		if err == nil {
+			err = l.UnLock(c, key, w)
+		}
+		written <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	if _, err = l.RLock(c, key, expire, wait); err == nil {
+		t.Errorf("%s should not be read while a writer is waiting", t.Name())
+	}
+	l.RUnLock(c, key, r1)
+	if err = <-written; err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+	}
+
+	// the writer giving up lets the readers in at once.
+	r1, _ = l.RLock(c, key, expire, wait)
+	if _, err = l.Lock(c, key, expire, wait); err == nil {
+		t.Errorf("%s should not be written while reading", t.Name())
+	}
+	r2, err = l.RLock(c, key, expire, wait)
+	if err != nil {
+		t.Errorf("%s has err[%+v] after the writer gave up", t.Name(), err)
+	}
+	l.RUnLock(c, key, r1)
+	l.RUnLock(c, key, r2)
+
+	// the expired reader does not block the writer.
+	if _, err = l.RLock(c, key, 20*time.Millisecond, wait); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+	}
+	if _, err = l.Lock(c, key, expire, 100*time.Millisecond); err != nil {
+		t.Errorf("%s has err[%+v] after the reader expired", t.Name(), err)
+	}
+
+	fmt.Println(t.Name())
+}
+
+func TestSemaphoreMemory(t *testing.T) {
+	db := memory.New()
+	defer db.Close()()
+
+	permits := 3
+	l := lock.NewSemaphore(db, int64(permits)).Duration(time.Millisecond)
+	c := context.Background()
+	key := "IamAKey"
+	expire := 10 * time.Second
+	wait := 10 * time.Millisecond
+
+	codes := make([]string, 0, permits)
+	for i := 0; i < permits; i++ {
+		code, err := l.Lock(c, key, expire, wait)
+		if err != nil {
+			t.Errorf("%s has err[%+v]", t.Name(), err)
+			return
+		}
+		codes = append(codes, code)
+	}
+	if _, err := l.Lock(c, key, expire, wait); err == nil {
+		t.Errorf("%s should not exceed %d permits", t.Name(), permits)
+	}
+	if err := l.UnLock(c, key, "IamNotTheOwner"); err == nil {
+		t.Errorf("%s should have err with wrong code", t.Name())
+	}
+	if err := l.UnLock(c, key, codes[0]); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+	}
+	if _, err := l.Lock(c, key, 20*time.Millisecond, wait); err != nil {
+		t.Errorf("%s has err[%+v] after released", t.Name(), err)
+	}
+
+	// the expired holder returns its permit.
+	if _, err := l.Lock(c, key, expire, 100*time.Millisecond); err != nil {
+		t.Errorf("%s has err[%+v] after the holder expired", t.Name(), err)
+	}
+	if _, err := lock.NewSemaphore(db, 0).Lock(c, key, expire, wait); !errors.Is(err, lock.ErrNoPermit) {
+		t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, lock.ErrNoPermit)
+	}
+
+	fmt.Println(t.Name())
+}
@@ -0,0 +1,73 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/neo532/gokit/database/memory"
+	"github.com/neo532/gokit/lock"
+)
+
+func TestLockCanceledMemory(t *testing.T) {
+	db := memory.New()
+	defer db.Close()()
+
+	l := lock.NewDistributedLock(db)
+	c := context.Background()
+	key := "IamAKey"
+
+	if _, err := l.Lock(c, key, 10*time.Second, time.Millisecond); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c, 50*time.Millisecond)
+	defer cancel()
+	begin := time.Now()
+	_, err := l.Lock(ctx, key, 10*time.Second, 10*time.Second)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, context.DeadlineExceeded)
+	}
+	if cost := time.Since(begin); cost > time.Second {
+		t.Errorf("%s returns too late %v", t.Name(), cost)
+	}
+
+	fmt.Println(t.Name())
+}
+
+func TestLockNotifyMemory(t *testing.T) {
+	db := memory.New()
+	defer db.Close()()
+
+	// polling once a few seconds, only the notification wakes it up in time.
+	l := lock.NewDistributedLock(db).Duration(5 * time.Second).MaxDuration(5 * time.Second).Notify(true)
+	c := context.Background()
+	key := "IamAKey"
+
+	code, err := l.Lock(c, key, 10*time.Second, time.Millisecond)
+	if err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+		return
+	}
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		l.UnLock(c, key, code)
+	}()
+
+	begin := time.Now()
+	if code, err = l.Lock(c, key, 10*time.Second, 10*time.Second); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+		return
+	}
+	if cost := time.Since(begin); cost > time.Second {
+		t.Errorf("%s is woken up too late %v", t.Name(), cost)
+	}
+	if err = l.UnLock(c, key, code); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+	}
+
+	fmt.Println(t.Name())
+}
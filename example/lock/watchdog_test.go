@@ -0,0 +1,73 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/neo532/gokit/database/memory"
+	"github.com/neo532/gokit/lock"
+)
+
+func TestWatchdogMemory(t *testing.T) {
+	db := memory.New()
+	defer db.Close()()
+
+	l := lock.NewDistributedLock(db).Duration(10 * time.Millisecond)
+	c := context.Background()
+	key := "IamAWatchdogKey"
+
+	w, err := l.LockWithWatchdog(c, key, 90*time.Millisecond, time.Second)
+	if err != nil {
+		t.Errorf("%s has error[%+v]", t.Name(), err)
+		return
+	}
+
+	// held longer than expire
+	time.Sleep(300 * time.Millisecond)
+	if _, err = l.Lock(c, key, time.Second, 50*time.Millisecond); err == nil {
+		t.Errorf("%s should not lock a renewed lock", t.Name())
+	}
+	select {
+	case <-w.Lost():
+		t.Errorf("%s has lost[%+v]", t.Name(), w.Err())
+	default:
+	}
+
+	if err = w.Unlock(c); err != nil {
+		t.Errorf("%s has error[%+v]", t.Name(), err)
+	}
+	if _, err = l.Lock(c, key, time.Second, 50*time.Millisecond); err != nil {
+		t.Errorf("%s has error[%+v]", t.Name(), err)
+	}
+
+	fmt.Println(t.Name())
+}
+
+func TestWatchdogLostMemory(t *testing.T) {
+	db := memory.New()
+	defer db.Close()()
+
+	l := lock.NewDistributedLock(db)
+	c := context.Background()
+	key := "IamALostKey"
+
+	w, err := l.LockWithWatchdog(c, key, 90*time.Millisecond, time.Second)
+	if err != nil {
+		t.Errorf("%s has error[%+v]", t.Name(), err)
+		return
+	}
+	db.Do(c, "DEL", "lock:"+key)
+
+	select {
+	case <-w.Lost():
+		if w.Err() == nil {
+			t.Errorf("%s should have the reason", t.Name())
+		}
+	case <-time.After(time.Second):
+		t.Errorf("%s should be lost", t.Name())
+	}
+
+	fmt.Println(t.Name())
+}
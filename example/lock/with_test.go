@@ -0,0 +1,93 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/neo532/gokit/database/memory"
+	"github.com/neo532/gokit/lock"
+)
+
+func TestWithLockMemory(t *testing.T) {
+	db := memory.New()
+	defer db.Close()()
+
+	l := lock.NewDistributedLock(db).Duration(time.Millisecond)
+	c := context.Background()
+	key := "IamAKey"
+	opts := lock.LockOptions{Expire: 10 * time.Second}
+
+	errBiz := errors.New("biz")
+	err := l.WithLock(c, key, opts, func(c context.Context) error {
+		if _, err := l.TryLock(c, key, time.Second); !errors.Is(err, lock.ErrNotAcquired) {
+			t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, lock.ErrNotAcquired)
+		}
+		_, err := l.Lock(c, key, time.Second, 10*time.Millisecond)
+		if !errors.Is(err, lock.ErrTimeout) || !errors.Is(err, lock.ErrNotAcquired) {
+			t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, lock.ErrTimeout)
+		}
+		if err = l.WithLock(c, key, opts, func(c context.Context) error { return nil }); !errors.Is(err, lock.ErrNotAcquired) {
+			t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, lock.ErrNotAcquired)
+		}
+		return errBiz
+	})
+	if !errors.Is(err, errBiz) {
+		t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, errBiz)
+	}
+
+	// released after fn.
+	code, err := l.TryLock(c, key, time.Second)
+	if err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+		return
+	}
+	if err = l.UnLock(c, key, "IamNotTheOwner"); !errors.Is(err, lock.ErrNotOwner) {
+		t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, lock.ErrNotOwner)
+	}
+	if err = l.UnLock(c, key, code); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+	}
+	if err = l.UnLock(c, key, code); !errors.Is(err, lock.ErrNotOwner) {
+		t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, lock.ErrNotOwner)
+	}
+
+	fmt.Println(t.Name())
+}
+
+func TestWithLockWatchdogMemory(t *testing.T) {
+	db := memory.New()
+	defer db.Close()()
+
+	l := lock.NewDistributedLock(db)
+	c := context.Background()
+	key := "IamAKey"
+	opts := lock.LockOptions{Expire: 30 * time.Millisecond, Wait: time.Second, Watchdog: true}
+
+	// renewed longer than expire.
+	err := l.WithLock(c, key, opts, func(c context.Context) error {
+		time.Sleep(100 * time.Millisecond)
+		return c.Err()
+	})
+	if err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+	}
+
+	// canceled after the lock is taken away.
+	err = l.WithLock(c, key, opts, func(c context.Context) error {
+		db.Do(context.Background(), "SET", "lock:"+key, "others")
+		select {
+		case <-c.Done():
+			return c.Err()
+		case <-time.After(time.Second):
+			return nil
+		}
+	})
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, lock.ErrNotOwner) {
+		t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, context.Canceled)
+	}
+
+	fmt.Println(t.Name())
+}
@@ -0,0 +1,167 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/neo532/gokit/database/memory"
+	"github.com/neo532/gokit/queue"
+	"github.com/neo532/gokit/queue/delay"
+	qmemory "github.com/neo532/gokit/queue/memory"
+	"github.com/neo532/gokit/queue/memory/producer"
+)
+
+func TraceID() queue.ProducerMiddleware {
+	return func(handler queue.ProducerHandler) queue.ProducerHandler {
+		return func(c context.Context, message any) (err error) {
+			c = queue.AppendHeaderToContext(c, "traceID", "abc")
+			return handler(c, message)
+		}
+	}
+}
+
+// failProducer fails count times before sending to the target.
+type failProducer struct {
+	queue.Producer
+	count int
+}
+
+func (p *failProducer) Send(c context.Context, message any) (err error) {
+	if p.count > 0 {
+		p.count--
+		return errors.New("send fail")
+	}
+	return p.Producer.Send(c, message)
+}
+
+func TestDelay(t *testing.T) {
+	db := memory.New()
+	defer db.Close()()
+	b := qmemory.NewBroker()
+	target := producer.New("default", b, producer.WithTopic("message"))
+
+	pdc := delay.New("message", db, target,
+		delay.WithInterval(10*time.Millisecond),
+		delay.WithMiddleware(TraceID()),
+	)
+	c, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go pdc.Run(c)
+
+	begin := time.Now()
+	if _, err := pdc.SendAfter(c, 100*time.Millisecond, map[string]int{"order": 1}); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+		return
+	}
+	id, _ := pdc.SendAfter(c, 100*time.Millisecond, map[string]int{"order": 2})
+	if err := pdc.Cancel(c, id); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+	}
+	if err := pdc.Cancel(c, id); !errors.Is(err, delay.ErrNotFound) {
+		t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, delay.ErrNotFound)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if ms := b.Messages("message"); len(ms) != 0 {
+		t.Errorf("%s has err[%d] should [0]", t.Name(), len(ms))
+	}
+	time.Sleep(150 * time.Millisecond)
+
+	ms := b.Messages("message")
+	if len(ms) != 1 {
+		t.Errorf("%s has err[%d] should [1]", t.Name(), len(ms))
+		return
+	}
+	if string(ms[0].Value) != `{"order":1}` || ms[0].Header.Value("traceID") != "abc" || ms[0].Timestamp.Sub(begin) < 100*time.Millisecond {
+		t.Errorf("%s has err[%s %+v %v]", t.Name(), ms[0].Value, ms[0].Header, ms[0].Timestamp.Sub(begin))
+	}
+	fmt.Println(t.Name())
+}
+
+func TestDelayLease(t *testing.T) {
+	db := memory.New()
+	defer db.Close()()
+	b := qmemory.NewBroker()
+	target := &failProducer{Producer: producer.New("default", b, producer.WithTopic("message")), count: 1}
+
+	pdc := delay.New("message", db, target, delay.WithLease(50*time.Millisecond))
+	c := context.Background()
+	pdc.SendAt(c, time.Now(), "hello")
+
+	// failed and hidden for lease.
+	if n := pdc.Move(c); n != 1 {
+		t.Errorf("%s has err[%d] should [1]", t.Name(), n)
+	}
+	if n := pdc.Move(c); n != 0 {
+		t.Errorf("%s has err[%d] should [0]", t.Name(), n)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if n := pdc.Move(c); n != 1 {
+		t.Errorf("%s has err[%d] should [1]", t.Name(), n)
+	}
+	if ms := b.Messages("message"); len(ms) != 1 {
+		t.Errorf("%s has err[%d] should [1]", t.Name(), len(ms))
+	}
+	if n := pdc.Move(c); n != 0 {
+		t.Errorf("%s has err[%d] should [0]", t.Name(), n)
+	}
+	fmt.Println(t.Name())
+}
+
+func TestDelayEncoder(t *testing.T) {
+	db := memory.New()
+	defer db.Close()()
+	b := qmemory.NewBroker()
+	text := func(message any) ([]byte, error) {
+		return []byte(fmt.Sprint(message)), nil
+	}
+	target := producer.New("default", b, producer.WithTopic("message"), producer.WithEncoder(text))
+
+	pdc := delay.New("message", db, target, delay.WithEncoder(text), delay.WithBatch(0))
+	c := context.Background()
+	pdc.SendAt(c, time.Now(), "hello")
+
+	if n := pdc.Move(c); n != 1 {
+		t.Errorf("%s has err[%d] should [1]", t.Name(), n)
+	}
+	if ms := b.Messages("message"); len(ms) != 1 || string(ms[0].Value) != "hello" {
+		t.Errorf("%s has err[%+v] should [hello]", t.Name(), ms)
+	}
+	fmt.Println(t.Name())
+}
+
+func TestDelayDead(t *testing.T) {
+	db := memory.New()
+	defer db.Close()()
+	b := qmemory.NewBroker()
+	target := producer.New("default", b, producer.WithTopic("message"))
+
+	pdc := delay.New("message", db, target, delay.WithLease(10*time.Millisecond))
+	c := context.Background()
+	if _, err := db.Eval(c, `redis.call('HSET', KEYS[2], 'bad', 'IamNotJson') return redis.call('ZADD', KEYS[1], 0, 'bad')`,
+		[]string{"delay:message", "delay:message:msg"}, nil,
+	); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+		return
+	}
+
+	if n := pdc.Move(c); n != 1 {
+		t.Errorf("%s has err[%d] should [1]", t.Name(), n)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := pdc.Move(c); n != 0 {
+		t.Errorf("%s has err[%d] should [0]", t.Name(), n)
+	}
+	value, err := db.Eval(c, `return redis.call('HGET', KEYS[1], ARGV[1])`, []string{"delay:message:dead"}, []any{"bad"})
+	if value != "IamNotJson" {
+		t.Errorf("%s has err[%v %+v] should [IamNotJson]", t.Name(), value, err)
+	}
+	if ms := b.Messages("message"); len(ms) != 0 {
+		t.Errorf("%s has err[%d] should [0]", t.Name(), len(ms))
+	}
+	fmt.Println(t.Name())
+}
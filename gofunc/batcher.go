@@ -0,0 +1,235 @@
+package gofunc
+
+/*
+ * @abstract aggregating the items of many goroutines into batches
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/neo532/gokit/errorx"
+)
+
+// ErrBatcherClosed is returned when adding to a closed Batcher.
+var ErrBatcherClosed = errors.New("gofunc: batcher closed")
+
+// BatchErrors is the errors of every item in a batch in order, returned by the function of Batcher,
+// so every caller receives the error of its own item.
+type BatchErrors []error
+
+func (e BatchErrors) Error() string {
+	return errors.Join(e...).Error()
+}
+
+// ========== BatchOpt ==========
+type batchOpt func(*batchOptions)
+
+type batchOptions struct {
+	maxSize   int
+	maxLinger time.Duration
+}
+
+// WithMaxSize sets the max count of items in a batch.
+func WithMaxSize(n int) batchOpt {
+	return func(o *batchOptions) {
+		o.maxSize = n
+	}
+}
+
+// WithMaxLinger sets the max waiting of the first item in a batch.
+func WithMaxLinger(t time.Duration) batchOpt {
+	return func(o *batchOptions) {
+		o.maxLinger = t
+	}
+}
+
+// ========== /BatchOpt ==========
+
+type batchItem[T, R any] struct {
+	item T
+	rst  chan batchResult[R]
+}
+
+type batchResult[R any] struct {
+	val R
+	err error
+}
+
+// Batcher collects the items added by many goroutines,
+// and calls fn with them when the batch reaches the max size or the max linger.
+type Batcher[T, R any] struct {
+	fn   func(c context.Context, items []T) ([]R, error)
+	opts batchOptions
+
+	in      chan batchItem[T, R]
+	lock    sync.RWMutex
+	closed  bool
+	quit    chan struct{}
+	senders sync.WaitGroup
+	done    chan struct{}
+}
+
+// NewBatcher returns a instance of Batcher, fn returns the results of items in order,
+// and its error goes to every item, or BatchErrors to each item.
+func NewBatcher[T, R any](fn func(c context.Context, items []T) ([]R, error), opts ...batchOpt) (b *Batcher[T, R]) {
+	b = &Batcher[T, R]{
+		fn: fn,
+		opts: batchOptions{
+			maxSize:   100,
+			maxLinger: 10 * time.Millisecond,
+		},
+		quit: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(&b.opts)
+	}
+	if b.opts.maxSize <= 0 {
+		b.opts.maxSize = 1
+	}
+	b.in = make(chan batchItem[T, R], b.opts.maxSize)
+	go b.loop()
+	return
+}
+
+// Add adds item to the batch and waits for its result until c is done.
+func (b *Batcher[T, R]) Add(c context.Context, item T) (val R, err error) {
+	b.lock.RLock()
+	if b.closed {
+		b.lock.RUnlock()
+		err = ErrBatcherClosed
+		return
+	}
+	b.senders.Add(1)
+	b.lock.RUnlock()
+
+	bi := batchItem[T, R]{item: item, rst: make(chan batchResult[R], 1)}
+	select {
+	case b.in <- bi:
+		b.senders.Done()
+	case <-b.quit:
+		b.senders.Done()
+		err = ErrBatcherClosed
+		return
+	case <-c.Done():
+		b.senders.Done()
+		err = c.Err()
+		return
+	}
+
+	select {
+	case r := <-bi.rst:
+		return r.val, r.err
+	case <-c.Done():
+		err = c.Err()
+		return
+	}
+}
+
+// Close stops accepting and flushes the added items, it waits for them until c is done.
+func (b *Batcher[T, R]) Close(c context.Context) (err error) {
+	b.lock.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.quit)
+		b.lock.Unlock()
+
+		b.senders.Wait()
+		close(b.in)
+	} else {
+		b.lock.Unlock()
+	}
+
+	select {
+	case <-b.done:
+	case <-c.Done():
+		err = c.Err()
+	}
+	return
+}
+
+func (b *Batcher[T, R]) loop() {
+	defer close(b.done)
+
+	batch := make([]batchItem[T, R], 0, b.opts.maxSize)
+	timer := time.NewTimer(b.opts.maxLinger)
+	timer.Stop()
+	var linger <-chan time.Time
+
+	flush := func() {
+		timer.Stop()
+		linger = nil
+		if len(batch) > 0 {
+			b.flush(batch)
+			batch = make([]batchItem[T, R], 0, b.opts.maxSize)
+		}
+	}
+
+	for {
+		select {
+		case bi, ok := <-b.in:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, bi)
+			if len(batch) >= b.opts.maxSize {
+				flush()
+				continue
+			}
+			if len(batch) == 1 {
+				timer.Reset(b.opts.maxLinger)
+				linger = timer.C
+			}
+		case <-linger:
+			flush()
+		}
+	}
+}
+
+// flush calls fn with batch and sends the result to every item.
+func (b *Batcher[T, R]) flush(batch []batchItem[T, R]) {
+	items := make([]T, 0, len(batch))
+	for _, bi := range batch {
+		items = append(items, bi.item)
+	}
+
+	rst, err := b.call(items)
+
+	var errs BatchErrors
+	if !errors.As(err, &errs) || len(errs) != len(batch) {
+		errs = nil
+	}
+	if err == nil && len(rst) != len(batch) {
+		err = errorx.New("[batcher][%d results for %d items]", len(rst), len(batch))
+	}
+
+	for i, bi := range batch {
+		var r batchResult[R]
+		switch {
+		case errs != nil:
+			r.err = errs[i]
+		case err != nil:
+			r.err = err
+		}
+		if i < len(rst) {
+			r.val = rst[i]
+		}
+		bi.rst <- r
+	}
+}
+
+func (b *Batcher[T, R]) call(items []T) (rst []R, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errorx.New("[batcher][%+v][%s]", r, string(debug.Stack()))
+		}
+	}()
+	return b.fn(context.Background(), items)
+}
@@ -0,0 +1,114 @@
+package gofunc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBatcher(t *testing.T) {
+	errOdd := errors.New("odd")
+
+	var batches atomic.Int32
+	var maxLen atomic.Int32
+	b := NewBatcher(func(c context.Context, items []int) ([]int, error) {
+		batches.Add(1)
+		if l := int32(len(items)); l > maxLen.Load() {
+			maxLen.Store(l)
+		}
+		rst := make([]int, len(items))
+		errs := make(BatchErrors, len(items))
+		for i, item := range items {
+			if item%2 == 1 {
+				errs[i] = errOdd
+				continue
+			}
+			rst[i] = item * 10
+		}
+		return rst, errs
+	}, WithMaxSize(10), WithMaxLinger(20*time.Millisecond))
+
+	c := context.Background()
+	l := 25
+	var wg sync.WaitGroup
+	wg.Add(l)
+	for i := 0; i < l; i++ {
+		go func(i int) {
+			defer wg.Done()
+			v, err := b.Add(c, i)
+			if i%2 == 1 {
+				if !errors.Is(err, errOdd) {
+					t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, errOdd)
+				}
+				return
+			}
+			if err != nil || v != i*10 {
+				t.Errorf("%s has wrong %d,%+v should %d", t.Name(), v, err, i*10)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if n := batches.Load(); n < 3 || maxLen.Load() > 10 {
+		t.Errorf("%s has wrong batches %d, max %d", t.Name(), n, maxLen.Load())
+	}
+
+	// flushed by linger.
+	begin := time.Now()
+	if v, err := b.Add(c, 2); err != nil || v != 20 {
+		t.Errorf("%s has wrong %d,%+v", t.Name(), v, err)
+	}
+	if cost := time.Since(begin); cost > time.Second {
+		t.Errorf("%s flushes too late %v", t.Name(), cost)
+	}
+
+	fmt.Println(t.Name())
+}
+
+func TestBatcherClose(t *testing.T) {
+	errBatch := errors.New("batch")
+	var flushed atomic.Int32
+	b := NewBatcher(func(c context.Context, items []string) ([]string, error) {
+		flushed.Add(int32(len(items)))
+		return nil, errBatch
+	}, WithMaxSize(100), WithMaxLinger(time.Hour))
+
+	c := context.Background()
+	var wg sync.WaitGroup
+	wg.Add(3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			defer wg.Done()
+			if _, err := b.Add(c, "item"); !errors.Is(err, errBatch) {
+				t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, errBatch)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	// drained on close, long before the linger.
+	if err := b.Close(c); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+	}
+	wg.Wait()
+	if flushed.Load() != 3 {
+		t.Errorf("%s has wrong flushed %d should %d", t.Name(), flushed.Load(), 3)
+	}
+	if _, err := b.Add(c, "item"); !errors.Is(err, ErrBatcherClosed) {
+		t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, ErrBatcherClosed)
+	}
+
+	b = NewBatcher(func(c context.Context, items []string) ([]string, error) {
+		panic("boom")
+	}, WithMaxSize(1))
+	if _, err := b.Add(c, "panic"); err == nil {
+		t.Errorf("%s should have err with panic", t.Name())
+	}
+	b.Close(c)
+
+	fmt.Println(t.Name())
+}
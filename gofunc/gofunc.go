@@ -8,6 +8,8 @@ package gofunc
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"runtime/debug"
 	"sync"
 	"time"
@@ -15,15 +17,14 @@ import (
 	"github.com/neo532/gokit/errorx"
 )
 
-const (
-	taskStatusEnd = -1
-)
-
 // GoFunc is a function for a goroutine.
 type GoFunc struct {
 	timeout      time.Duration
 	log          Logger
 	maxGoroutine int
+	taskTimeout  time.Duration
+	failFast     bool
+	retry        *Retry
 }
 
 // opt is a object for guard goroutine and panic.
@@ -43,6 +44,27 @@ func WithMaxGoroutine(n int) opt {
 	}
 }
 
+// WithTaskTimeout sets the timeout of every task.
+func WithTaskTimeout(t time.Duration) opt {
+	return func(v *GoFunc) {
+		v.taskTimeout = t
+	}
+}
+
+// WithRetry retries every task by r, the failed attempts are reported to the logger of GoFunc.
+func WithRetry(r *Retry) opt {
+	return func(v *GoFunc) {
+		v.retry = r
+	}
+}
+
+// WithFailFast cancels the rest tasks on the first error and returns it, used by Map and Run.
+func WithFailFast() opt {
+	return func(v *GoFunc) {
+		v.failFast = true
+	}
+}
+
 // NewGoFunc returns a instance of GoFunc.
 func NewGoFunc(opts ...opt) *GoFunc {
 	gf := &GoFunc{
@@ -55,106 +77,213 @@ func NewGoFunc(opts ...opt) *GoFunc {
 	return gf
 }
 
-func (g *GoFunc) goWithTimeout(c context.Context, ts time.Duration, fns ...func(i int) error) {
+// Task is a function run by GoFunc with the index of it,
+// c is done when the context of caller is done, the whole timeout or the timeout of task is reached.
+type Task func(c context.Context, i int) error
+
+var (
+	// ErrTimeout is returned when the tasks failed to finish within the whole timeout.
+	ErrTimeout = errors.New("gofunc: timeout")
+	// ErrTaskTimeout is the cause of the context of a task reaching WithTaskTimeout.
+	ErrTaskTimeout = errors.New("gofunc: task timeout")
+)
+
+// goWithTimeout runs fns until they are done, c is done or ts is reached.
+// With wait, the running ones are not abandoned when c is done,
+// but only when ts is reached before it, as the functions without context can not stop.
+func (g *GoFunc) goWithTimeout(c context.Context, ts time.Duration, wait bool, fns ...Task) (err error) {
+
+	ctx := c
+	if ts > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(c, ts, ErrTimeout)
+		defer cancel()
+	}
+
+	// fail fast
+	ctx, fail := context.WithCancelCause(ctx)
+	defer fail(nil)
+	var first error
+	var once sync.Once
+
+	// the parent of the context of the running ones.
+	run := ctx
+	if wait {
+		var abandon context.CancelCauseFunc
+		run, abandon = context.WithCancelCause(context.WithoutCancel(c))
+		defer abandon(nil)
+		// keep waiting once c is done before ts.
+		stop := context.AfterFunc(ctx, func() {
+			if e := context.Cause(ctx); errors.Is(e, ErrTimeout) && c.Err() == nil {
+				abandon(e)
+			}
+		})
+		defer stop()
+	}
 
 	l := len(fns)
 	lRunning := g.maxGoroutine
-	if l < lRunning {
+	if lRunning <= 0 || l < lRunning {
 		lRunning = l
 	}
 
 	var wg sync.WaitGroup
-	wg.Add(lRunning + 1)
+	wg.Add(lRunning)
 	task := make(chan int)
-	defer close(task)
-
-	go func() {
 
-		defer func() {
-			if r := recover(); r != nil {
-				g.log.Error(c,
-					errorx.New("[producer][%+v][%s]", r, string(debug.Stack())),
-				)
-			}
-			for i := 0; i < lRunning; i++ {
-				task <- taskStatusEnd
+	for i := 0; i < lRunning; i++ {
+		go func() {
+			defer wg.Done()
+			for index := range task {
+				if e := g.runTask(ctx, run, index, fns[index]); e != nil && g.failFast {
+					once.Do(func() {
+						first = e
+						fail(e)
+					})
+				}
 			}
-			wg.Done()
 		}()
+	}
 
-		if int(ts.Microseconds()) == 0 {
-			for i := 0; i < l; i++ {
-				task <- i
-			}
-			return
+	dispatched := 0
+dispatch:
+	for ; dispatched < l && ctx.Err() == nil; dispatched++ {
+		select {
+		case task <- dispatched:
+		case <-ctx.Done():
+			break dispatch
 		}
+	}
+	close(task)
+	wg.Wait()
 
-		timer := time.NewTimer(ts)
-		defer timer.Stop()
-
-		for i := 0; i < l; i++ {
-			select {
-			case <-timer.C:
-				g.log.Error(c,
-					errorx.New("Timeout!,goroutines faild to finish within the specified %v", ts),
-				)
-				return
-			default:
-				task <- i
+	if ctx.Err() == nil || (wait && dispatched == l && first == nil && run.Err() == nil) {
+		return
+	}
+	err = context.Cause(ctx)
+	if first != nil {
+		err = first
+	}
+	if errors.Is(err, ErrTimeout) {
+		g.log.Error(c,
+			errorx.New("Timeout!,goroutines faild to finish within the specified %v", ts),
+		)
+	}
+	if dispatched < l {
+		g.log.Error(c,
+			errorx.New("[%d tasks not run][%+v]", l-dispatched, err),
+		)
+	}
+	return
+}
+
+// runTask runs fn if c is not done and waits for it until the context of it derived from run is done,
+// the abandoned one is reported to the logger and keeps running in background.
+// It returns the error of fn matched by errors.Is, or the one of panic, abandoned or not run.
+func (g *GoFunc) runTask(c context.Context, run context.Context, index int, fn Task) (err error) {
+	if c.Err() != nil {
+		err = errorx.New("[%dth not run][%+v]", index, context.Cause(c))
+		g.log.Error(c, err)
+		return
+	}
+
+	tc, cancel := run, context.CancelFunc(func() {})
+	if g.taskTimeout > 0 {
+		tc, cancel = context.WithTimeoutCause(tc, g.taskTimeout, ErrTaskTimeout)
+	}
+	defer cancel()
+
+	rst := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				rst <- errorx.New("[%dth][%+v][%s]", index, r, string(debug.Stack()))
 			}
+		}()
+		if err := g.call(tc, index, fn); err != nil {
+			rst <- fmt.Errorf("[%dth]: %w", index, err)
+			return
 		}
+		rst <- nil
 	}()
 
-	for i := 0; i < lRunning; i++ {
-		go func() {
-			var index int
-			defer func() {
-				if r := recover(); r != nil {
-
-					g.log.Error(c,
-						errorx.New("[%dth][%+v][%s]", index, r, string(debug.Stack())),
-					)
-					// pop taskStatusEnd
-					<-task
-				}
-				wg.Done()
-			}()
+	select {
+	case err = <-rst:
+	case <-tc.Done():
+		// it may be finished at the same time.
+		select {
+		case err = <-rst:
+		default:
+			err = errorx.New("[%dth abandoned][%+v]", index, context.Cause(tc))
+		}
+	}
+	if err != nil {
+		g.log.Error(c, errorx.Wrap(err))
+	}
+	return
+}
 
-			for {
-				if index = <-task; index == taskStatusEnd {
-					return
-				}
+// call runs fn with the retry of g within the timeout of task.
+func (g *GoFunc) call(c context.Context, index int, fn Task) error {
+	if g.retry == nil {
+		return fn(c, index)
+	}
+	return g.retry.do(c, g.log, fmt.Sprintf("[%dth]", index), func(c context.Context) error {
+		return fn(c, index)
+	})
+}
 
-				if err := fns[index](index); err != nil {
-					g.log.Error(c, errorx.Wrapf(err, "[%dth]", index))
-				}
-			}
-		}()
+// toTasks adapts the functions without context.
+func toTasks(fns []func(i int) error) []Task {
+	ts := make([]Task, 0, len(fns))
+	for _, fn := range fns {
+		ts = append(ts, func(c context.Context, i int) error {
+			return fn(i)
+		})
 	}
+	return ts
+}
 
-	wg.Wait()
+// RunWithTimeout runs tasks with the context derived from c synchronously,
+// and returns promptly with ErrTimeout after ts, abandoning the stuck tasks.
+// The errors, panics and abandoned tasks are reported to the logger.
+func (g *GoFunc) RunWithTimeout(c context.Context, ts time.Duration, fns ...Task) error {
+	return g.goWithTimeout(c, ts, false, fns...)
+}
+
+// Run runs tasks with the context derived from c synchronously,
+// and returns promptly with the cause of c when c is done.
+// WithFailFast cancels the context of the rest on the first error and returns it.
+func (g *GoFunc) Run(c context.Context, fns ...Task) error {
+	return g.goWithTimeout(c, 0, false, fns...)
 }
 
-// WithTimeout is a way that running groutine slice by limiting time is synchronized.
-func (g *GoFunc) WithTimeout(c context.Context, ts time.Duration, fns ...func(i int) error) {
-	g.goWithTimeout(c, ts, fns...)
+// WithTimeout is a way that running groutine slice by limiting time is synchronized,
+// it returns promptly with ErrTimeout after ts, abandoning the running ones and reporting them to the logger.
+// When c is done before ts, the rest are not run but the running ones are waited for until done or WithTaskTimeout,
+// as they can not see the context.
+func (g *GoFunc) WithTimeout(c context.Context, ts time.Duration, fns ...func(i int) error) error {
+	return g.goWithTimeout(c, ts, true, toTasks(fns)...)
 }
 
-// Go is a way that running groutine slice is synchronized.
-func (g *GoFunc) Go(c context.Context, fns ...func(i int) error) {
-	g.goWithTimeout(c, time.Second*0, fns...)
+// Go is a way that running groutine slice is synchronized,
+// when c is done the running ones are waited for like WithTimeout.
+func (g *GoFunc) Go(c context.Context, fns ...func(i int) error) error {
+	return g.goWithTimeout(c, 0, true, toTasks(fns)...)
 }
 
-// AsyncWithTimeout is a way that running groutine slice by limiting time is asynchronized.
+// AsyncWithTimeout is a way that running groutine slice by limiting time is asynchronized,
+// the tasks are not canceled with c, as the caller does not wait for them.
 func (g *GoFunc) AsyncWithTimeout(c context.Context, ts time.Duration, fns ...func(i int) error) {
 	go func() {
-		g.WithTimeout(c, ts, fns...)
+		g.WithTimeout(context.WithoutCancel(c), ts, fns...)
 	}()
 }
 
-// AsyncGo is a way that running groutine slice is asynchronized.
+// AsyncGo is a way that running groutine slice is asynchronized,
+// the tasks are not canceled with c, as the caller does not wait for them.
 func (g *GoFunc) AsyncGo(c context.Context, fns ...func(i int) error) {
 	go func() {
-		g.Go(c, fns...)
+		g.Go(context.WithoutCancel(c), fns...)
 	}()
 }
@@ -2,6 +2,7 @@ package gofunc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync/atomic"
 	"testing"
@@ -53,3 +54,147 @@ func TestWithTimeout(t *testing.T) {
 
 	fmt.Println(t.Name())
 }
+
+func TestWithTimeoutStuck(t *testing.T) {
+
+	stuck := make(chan struct{})
+	defer close(stuck)
+
+	var num atomic.Int32
+	fns := []func(i int) error{
+		func(i int) error {
+			<-stuck
+			return nil
+		},
+		func(i int) error {
+			num.Add(1)
+			return nil
+		},
+	}
+
+	log := &DefaultLogger{}
+	gofn := NewGoFunc(WithLogger(log))
+
+	begin := time.Now()
+	err := gofn.WithTimeout(context.Background(), 50*time.Millisecond, fns...)
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, ErrTimeout)
+	}
+	if cost := time.Since(begin); cost > time.Second {
+		t.Errorf("%s returns too late %v", t.Name(), cost)
+	}
+	if log.Err() == nil {
+		t.Errorf("%s should report the abandoned task", t.Name())
+	}
+	if i := int(num.Load()); i != 1 {
+		t.Errorf("%s has wrong %d should %d", t.Name(), i, 1)
+	}
+
+	fmt.Println(t.Name())
+}
+
+func TestRunWithTimeoutStuck(t *testing.T) {
+
+	stuck := make(chan struct{})
+	defer close(stuck)
+
+	var num atomic.Int32
+	fns := []Task{
+		func(c context.Context, i int) error {
+			// ignores the context.
+			<-stuck
+			return nil
+		},
+		func(c context.Context, i int) error {
+			num.Add(1)
+			return nil
+		},
+	}
+
+	log := &DefaultLogger{}
+	gofn := NewGoFunc(WithLogger(log))
+
+	begin := time.Now()
+	err := gofn.RunWithTimeout(context.Background(), 50*time.Millisecond, fns...)
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, ErrTimeout)
+	}
+	if cost := time.Since(begin); cost > time.Second {
+		t.Errorf("%s returns too late %v", t.Name(), cost)
+	}
+	if log.Err() == nil {
+		t.Errorf("%s should report the abandoned task", t.Name())
+	}
+	if i := int(num.Load()); i != 1 {
+		t.Errorf("%s has wrong %d should %d", t.Name(), i, 1)
+	}
+
+	fmt.Println(t.Name())
+}
+
+var errFail = errors.New("fail")
+
+func TestRun(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		opts    []opt
+		timeout time.Duration
+		fail    bool
+		err     error
+		logged  bool
+	}{
+		{
+			name:   "task timeout",
+			opts:   []opt{WithTaskTimeout(20 * time.Millisecond)},
+			logged: true,
+		},
+		{
+			name:    "canceled",
+			timeout: 20 * time.Millisecond,
+			err:     context.DeadlineExceeded,
+			logged:  true,
+		},
+		{
+			name:   "fail fast",
+			opts:   []opt{WithFailFast()},
+			fail:   true,
+			err:    errFail,
+			logged: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := &DefaultLogger{}
+			gofn := NewGoFunc(append(tt.opts, WithLogger(log), WithMaxGoroutine(2))...)
+
+			c := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				c, cancel = context.WithTimeout(c, tt.timeout)
+				defer cancel()
+			}
+
+			fn := func(c context.Context, i int) error {
+				if tt.fail && i == 1 {
+					return errFail
+				}
+				<-c.Done()
+				return c.Err()
+			}
+			begin := time.Now()
+			if err := gofn.Run(c, fn, fn, fn); !errors.Is(err, tt.err) {
+				t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, tt.err)
+			}
+			if cost := time.Since(begin); cost > time.Second {
+				t.Errorf("%s returns too late %v", t.Name(), cost)
+			}
+			if (log.Err() != nil) != tt.logged {
+				t.Errorf("%s has wrong log[%+v]", t.Name(), log.Err())
+			}
+		})
+	}
+
+	fmt.Println(t.Name())
+}
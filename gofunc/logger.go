@@ -37,3 +37,12 @@ func (l *DefaultLogger) Error(c context.Context, err error) {
 func (l *DefaultLogger) Err() error {
 	return l.err
 }
+
+// NopLogger drops the errors, for the long-lived ones like Pool and Retry.
+type NopLogger struct{}
+
+func (NopLogger) Error(c context.Context, err error) {}
+
+func (NopLogger) Err() error {
+	return nil
+}
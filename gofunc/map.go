@@ -0,0 +1,110 @@
+package gofunc
+
+/*
+ * @abstract running a function on every item concurrently and collecting the results
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	"github.com/neo532/gokit/errorx"
+)
+
+// Map runs fn on every item with at most WithMaxGoroutine goroutines,
+// and returns the results in the order of items.
+// By default it runs all items and returns the joined errors, matched by errors.Is and errors.As.
+// WithFailFast cancels the context of the rest on the first error and returns it.
+// WithTaskTimeout limits and WithRetry retries every fn.
+// A panic of fn is returned as an error with the stack.
+func Map[T, R any](c context.Context, items []T, fn func(c context.Context, item T) (R, error), opts ...opt) (rst []R, err error) {
+	g := NewGoFunc(opts...)
+	rst = make([]R, len(items))
+	if len(items) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(c)
+	defer cancel()
+
+	lRunning := g.maxGoroutine
+	if lRunning <= 0 || len(items) < lRunning {
+		lRunning = len(items)
+	}
+
+	errs := make([]error, len(items))
+	var first error
+	var once sync.Once
+
+	task := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(lRunning)
+	for i := 0; i < lRunning; i++ {
+		go func() {
+			defer wg.Done()
+			for index := range task {
+				if e := ctx.Err(); e != nil {
+					errs[index] = fmt.Errorf("[%dth]: %w", index, e)
+					continue
+				}
+				if errs[index] = call(ctx, g, index, items[index], fn, &rst[index]); errs[index] != nil && g.failFast {
+					once.Do(func() {
+						first = errs[index]
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+	dispatched := 0
+dispatch:
+	for ; dispatched < len(items) && ctx.Err() == nil; dispatched++ {
+		select {
+		case task <- dispatched:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(task)
+	wg.Wait()
+
+	if first != nil {
+		err = first
+		return
+	}
+	if dispatched < len(items) {
+		errs = append(errs, fmt.Errorf("[%d items not run]: %w", len(items)-dispatched, c.Err()))
+	}
+	err = errors.Join(errs...)
+	return
+}
+
+// call runs fn on item within the timeout of task and saves the result to r, turning the panic into an error.
+func call[T, R any](c context.Context, g *GoFunc, index int, item T, fn func(c context.Context, item T) (R, error), r *R) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = errorx.New("[%dth][%+v][%s]", index, p, string(debug.Stack()))
+		}
+	}()
+
+	if g.taskTimeout > 0 {
+		var cancel context.CancelFunc
+		c, cancel = context.WithTimeoutCause(c, g.taskTimeout, ErrTaskTimeout)
+		defer cancel()
+	}
+
+	err = g.call(c, index, func(c context.Context, i int) (err error) {
+		*r, err = fn(c, item)
+		return
+	})
+	if err != nil {
+		err = fmt.Errorf("[%dth]: %w", index, err)
+	}
+	return
+}
@@ -0,0 +1,122 @@
+package gofunc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMap(t *testing.T) {
+	errOdd := errors.New("odd")
+	items := []int{5, 4, 3, 2, 1}
+
+	tests := []struct {
+		name  string
+		opts  []opt
+		fn    func(c context.Context, item int) (string, error)
+		rst   []string
+		err   error
+		ran   int32
+		check func(err error) bool
+	}{
+		{
+			name: "order",
+			opts: []opt{WithMaxGoroutine(2)},
+			fn: func(c context.Context, item int) (string, error) {
+				time.Sleep(time.Duration(item) * time.Millisecond)
+				return strconv.Itoa(item), nil
+			},
+			rst: []string{"5", "4", "3", "2", "1"},
+			ran: 5,
+		},
+		{
+			name: "collect all",
+			fn: func(c context.Context, item int) (string, error) {
+				if item%2 == 1 {
+					return "", errOdd
+				}
+				return strconv.Itoa(item), nil
+			},
+			rst: []string{"", "4", "", "2", ""},
+			err: errOdd,
+			ran: 5,
+		},
+		{
+			name: "fail fast",
+			opts: []opt{WithMaxGoroutine(1), WithFailFast()},
+			fn: func(c context.Context, item int) (string, error) {
+				if item == 4 {
+					return "", errOdd
+				}
+				return strconv.Itoa(item), nil
+			},
+			rst: []string{"5", "", "", "", ""},
+			err: errOdd,
+			ran: 2,
+		},
+		{
+			name: "panic",
+			fn: func(c context.Context, item int) (string, error) {
+				if item == 3 {
+					panic("boom")
+				}
+				return strconv.Itoa(item), nil
+			},
+			rst: []string{"5", "4", "", "2", "1"},
+			ran: 5,
+			check: func(err error) bool {
+				return err != nil
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ran atomic.Int32
+			rst, err := Map(context.Background(), items, func(c context.Context, item int) (string, error) {
+				ran.Add(1)
+				return tt.fn(c, item)
+			}, tt.opts...)
+
+			if tt.check != nil {
+				if !tt.check(err) {
+					t.Errorf("%s has wrong err[%+v]", t.Name(), err)
+				}
+			} else if !errors.Is(err, tt.err) {
+				t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, tt.err)
+			}
+			if fmt.Sprint(rst) != fmt.Sprint(tt.rst) {
+				t.Errorf("%s has wrong %v should %v", t.Name(), rst, tt.rst)
+			}
+			if ran.Load() != tt.ran {
+				t.Errorf("%s has wrong ran %d should %d", t.Name(), ran.Load(), tt.ran)
+			}
+		})
+	}
+
+	fmt.Println(t.Name())
+}
+
+func TestMapCanceled(t *testing.T) {
+	c, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	items := make([]int, 100)
+	_, err := Map(c, items, func(c context.Context, item int) (int, error) {
+		select {
+		case <-c.Done():
+			return 0, c.Err()
+		case <-time.After(10 * time.Millisecond):
+			return item, nil
+		}
+	}, WithMaxGoroutine(1))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, context.DeadlineExceeded)
+	}
+
+	fmt.Println(t.Name())
+}
@@ -0,0 +1,243 @@
+package gofunc
+
+/*
+ * @abstract persistent bounded worker pool
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+
+	"github.com/neo532/gokit/errorx"
+)
+
+var (
+	// ErrPoolClosed is returned when submitting to a shutdown pool.
+	ErrPoolClosed = errors.New("gofunc: pool closed")
+	// ErrPoolFull is returned by TrySubmit when the queue is full.
+	ErrPoolFull = errors.New("gofunc: pool full")
+)
+
+// RejectPolicy is the way of TrySubmit when the queue is full.
+type RejectPolicy int
+
+const (
+	// RejectAbort returns ErrPoolFull.
+	RejectAbort RejectPolicy = iota
+	// RejectCallerRuns runs the task in the goroutine of the caller.
+	RejectCallerRuns
+	// RejectDiscardOldest drops the oldest task in the queue, which is reported to the logger.
+	RejectDiscardOldest
+)
+
+// PoolStats is the snapshot of the pool.
+type PoolStats struct {
+	Workers   int
+	Active    int64
+	Queued    int
+	Completed uint64
+	Panicked  uint64
+	Rejected  uint64
+}
+
+type poolTask struct {
+	c  context.Context
+	fn func(c context.Context) error
+}
+
+// ========== PoolOpt ==========
+type poolOpt func(*Pool)
+
+// WithPoolLogger sets the handle of error for Pool, the errors are dropped by default.
+func WithPoolLogger(log Logger) poolOpt {
+	return func(p *Pool) {
+		p.log = log
+	}
+}
+
+// WithRejectPolicy sets the policy of TrySubmit when the queue is full.
+func WithRejectPolicy(policy RejectPolicy) poolOpt {
+	return func(p *Pool) {
+		p.policy = policy
+	}
+}
+
+// ========== /PoolOpt ==========
+
+// Pool is a long-lived pool with fixed workers and a bounded queue.
+type Pool struct {
+	workers int
+	queue   chan poolTask
+	log     Logger
+	policy  RejectPolicy
+
+	lock    sync.RWMutex
+	closed  bool
+	quit    chan struct{}
+	senders sync.WaitGroup
+	done    chan struct{}
+
+	active    atomic.Int64
+	completed atomic.Uint64
+	panicked  atomic.Uint64
+	rejected  atomic.Uint64
+}
+
+// NewPool returns a instance of Pool with workers goroutines and a queue of size.
+func NewPool(workers, size int, opts ...poolOpt) (p *Pool) {
+	if workers <= 0 {
+		workers = 1
+	}
+	if size < 0 {
+		size = 0
+	}
+	p = &Pool{
+		workers: workers,
+		queue:   make(chan poolTask, size),
+		log:     NopLogger{},
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(p)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for t := range p.queue {
+				p.run(t)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(p.done)
+	}()
+	return
+}
+
+// Submit queues fn and blocks while the queue is full, until c is done or the pool is shutdown.
+// fn runs with c, and its error or panic is reported to the logger.
+func (p *Pool) Submit(c context.Context, fn func(c context.Context) error) (err error) {
+	if err = p.enter(); err != nil {
+		return
+	}
+	defer p.senders.Done()
+
+	select {
+	case p.queue <- poolTask{c: c, fn: fn}:
+	case <-p.quit:
+		err = ErrPoolClosed
+	case <-c.Done():
+		err = c.Err()
+	}
+	return
+}
+
+// TrySubmit queues fn without blocking, and handles it by the RejectPolicy when the queue is full.
+func (p *Pool) TrySubmit(c context.Context, fn func(c context.Context) error) (err error) {
+	if err = p.enter(); err != nil {
+		return
+	}
+	defer p.senders.Done()
+
+	t := poolTask{c: c, fn: fn}
+	select {
+	case p.queue <- t:
+		return
+	default:
+	}
+
+	switch p.policy {
+	case RejectCallerRuns:
+		p.run(t)
+		return
+	case RejectDiscardOldest:
+		select {
+		case old := <-p.queue:
+			p.rejected.Add(1)
+			p.log.Error(old.c, errorx.New("[discarded][%+v]", ErrPoolFull))
+		default:
+		}
+		select {
+		case p.queue <- t:
+			return
+		default:
+		}
+	}
+	p.rejected.Add(1)
+	err = ErrPoolFull
+	return
+}
+
+// Shutdown stops accepting and waits for the queued and running tasks until c is done.
+func (p *Pool) Shutdown(c context.Context) (err error) {
+	p.lock.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.quit)
+		p.lock.Unlock()
+
+		// no sender is blocked after quit.
+		p.senders.Wait()
+		close(p.queue)
+	} else {
+		p.lock.Unlock()
+	}
+
+	select {
+	case <-p.done:
+	case <-c.Done():
+		err = c.Err()
+	}
+	return
+}
+
+// Stats returns the snapshot of the pool.
+func (p *Pool) Stats() PoolStats {
+	return PoolStats{
+		Workers:   p.workers,
+		Active:    p.active.Load(),
+		Queued:    len(p.queue),
+		Completed: p.completed.Load(),
+		Panicked:  p.panicked.Load(),
+		Rejected:  p.rejected.Load(),
+	}
+}
+
+func (p *Pool) enter() error {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	if p.closed {
+		return ErrPoolClosed
+	}
+	p.senders.Add(1)
+	return nil
+}
+
+func (p *Pool) run(t poolTask) {
+	p.active.Add(1)
+	defer func() {
+		if r := recover(); r != nil {
+			p.panicked.Add(1)
+			p.log.Error(t.c,
+				errorx.New("[pool][%+v][%s]", r, string(debug.Stack())),
+			)
+		} else {
+			p.completed.Add(1)
+		}
+		p.active.Add(-1)
+	}()
+
+	if err := t.fn(t.c); err != nil {
+		p.log.Error(t.c, errorx.Wrapf(err, "[pool]"))
+	}
+}
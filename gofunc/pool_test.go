@@ -0,0 +1,130 @@
+package gofunc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPool(t *testing.T) {
+	log := &DefaultLogger{}
+	p := NewPool(2, 4, WithPoolLogger(log))
+	c := context.Background()
+
+	var num atomic.Int32
+	l := 20
+	for i := 0; i < l; i++ {
+		if err := p.Submit(c, func(c context.Context) error {
+			time.Sleep(time.Millisecond)
+			num.Add(1)
+			return nil
+		}); err != nil {
+			t.Errorf("%s has err[%+v]", t.Name(), err)
+		}
+	}
+	p.Submit(c, func(c context.Context) error {
+		panic("boom")
+	})
+
+	if err := p.Shutdown(c); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+	}
+	if i := int(num.Load()); i != l {
+		t.Errorf("%s has wrong %d should %d", t.Name(), i, l)
+	}
+	if s := p.Stats(); s.Completed != uint64(l) || s.Panicked != 1 || s.Active != 0 || s.Queued != 0 {
+		t.Errorf("%s has wrong stats %+v", t.Name(), s)
+	}
+	if log.Err() == nil {
+		t.Errorf("%s should report the panic", t.Name())
+	}
+	if err := p.Submit(c, func(c context.Context) error { return nil }); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, ErrPoolClosed)
+	}
+
+	fmt.Println(t.Name())
+}
+
+func TestPoolReject(t *testing.T) {
+	c := context.Background()
+
+	tests := []struct {
+		name     string
+		policy   RejectPolicy
+		err      error
+		ran      int32
+		rejected uint64
+	}{
+		{name: "abort", policy: RejectAbort, err: ErrPoolFull, ran: 1, rejected: 1},
+		{name: "caller runs", policy: RejectCallerRuns, ran: 2},
+		{name: "discard oldest", policy: RejectDiscardOldest, ran: 1, rejected: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPool(1, 1, WithRejectPolicy(tt.policy))
+
+			// the worker is busy and the queue is full.
+			block := make(chan struct{})
+			started := make(chan struct{})
+			p.Submit(c, func(c context.Context) error {
+				close(started)
+				<-block
+				return nil
+			})
+			<-started
+
+			var ran atomic.Int32
+			p.Submit(c, func(c context.Context) error {
+				ran.Add(1)
+				return nil
+			})
+			if s := p.Stats(); s.Active != 1 || s.Queued != 1 {
+				t.Errorf("%s has wrong stats %+v", t.Name(), s)
+			}
+
+			if err := p.TrySubmit(c, func(c context.Context) error {
+				ran.Add(1)
+				return nil
+			}); !errors.Is(err, tt.err) {
+				t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, tt.err)
+			}
+
+			// Submit is blocked by the full queue.
+			ctx, cancel := context.WithTimeout(c, 10*time.Millisecond)
+			defer cancel()
+			if err := p.Submit(ctx, func(c context.Context) error { return nil }); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, context.DeadlineExceeded)
+			}
+
+			close(block)
+			p.Shutdown(c)
+			if ran.Load() != tt.ran || p.Stats().Rejected != tt.rejected {
+				t.Errorf("%s has wrong ran %d, stats %+v", t.Name(), ran.Load(), p.Stats())
+			}
+		})
+	}
+
+	fmt.Println(t.Name())
+}
+
+func TestPoolShutdownTimeout(t *testing.T) {
+	p := NewPool(1, 1)
+	block := make(chan struct{})
+	defer close(block)
+	p.Submit(context.Background(), func(c context.Context) error {
+		<-block
+		return nil
+	})
+
+	c, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Shutdown(c); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, context.DeadlineExceeded)
+	}
+
+	fmt.Println(t.Name())
+}
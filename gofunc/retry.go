@@ -0,0 +1,116 @@
+package gofunc
+
+/*
+ * @abstract retry with backoff
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/neo532/gokit/errorx"
+)
+
+// ========== RetryOpt ==========
+type retryOpt func(*Retry)
+
+// WithAttempts sets the max attempts including the first one.
+func WithAttempts(n int) retryOpt {
+	return func(r *Retry) {
+		r.attempts = n
+	}
+}
+
+// WithBackoff sets the first and the max backoff, it doubles with jitter on every retry.
+func WithBackoff(base, max time.Duration) retryOpt {
+	return func(r *Retry) {
+		r.base = base
+		r.max = max
+	}
+}
+
+// WithRetryable sets the predicate of the retryable error, all errors are retryable by default.
+func WithRetryable(fn func(err error) bool) retryOpt {
+	return func(r *Retry) {
+		r.retryable = fn
+	}
+}
+
+// WithRetryLogger sets the logger reporting every failed attempt of Do, the errors are dropped by default.
+func WithRetryLogger(log Logger) retryOpt {
+	return func(r *Retry) {
+		r.log = log
+	}
+}
+
+// ========== /RetryOpt ==========
+
+// Retry runs a function until it succeeds, the error is not retryable or the attempts are used up.
+type Retry struct {
+	attempts  int
+	base      time.Duration
+	max       time.Duration
+	retryable func(err error) bool
+	log       Logger
+}
+
+// NewRetry returns a instance of Retry.
+func NewRetry(opts ...retryOpt) (r *Retry) {
+	r = &Retry{
+		attempts:  3,
+		base:      100 * time.Millisecond,
+		max:       time.Second,
+		retryable: func(err error) bool { return true },
+		log:       NopLogger{},
+	}
+	for _, o := range opts {
+		o(r)
+	}
+	return
+}
+
+// Do runs fn with retries and returns the last error,
+// it returns at once with the error of c joined when c is done during the backoff.
+func (r *Retry) Do(c context.Context, fn func(c context.Context) error) error {
+	return r.do(c, r.log, "", fn)
+}
+
+// do reports every failed attempt to log with the tag.
+func (r *Retry) do(c context.Context, log Logger, tag string, fn func(c context.Context) error) (err error) {
+	backoff := r.base
+	for attempt := 1; ; attempt++ {
+		if err = fn(c); err == nil {
+			return
+		}
+		log.Error(c, errorx.Wrapf(err, "%s[attempt %d/%d]", tag, attempt, r.attempts))
+
+		if attempt >= r.attempts || !r.retryable(err) {
+			return
+		}
+
+		t := time.NewTimer(jitter(backoff))
+		select {
+		case <-c.Done():
+			t.Stop()
+			err = errors.Join(err, c.Err())
+			return
+		case <-t.C:
+		}
+
+		if backoff *= 2; backoff > r.max {
+			backoff = max(r.max, r.base)
+		}
+	}
+}
+
+// jitter returns a random duration in [d/2, d].
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
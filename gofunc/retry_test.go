@@ -0,0 +1,115 @@
+package gofunc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	errFlaky := errors.New("flaky")
+	errFatal := errors.New("fatal")
+
+	tests := []struct {
+		name     string
+		opts     []retryOpt
+		fails    int32
+		failErr  error
+		timeout  time.Duration
+		err      error
+		attempts int32
+	}{
+		{
+			name:     "succeed after retries",
+			fails:    2,
+			failErr:  errFlaky,
+			attempts: 3,
+		},
+		{
+			name:     "attempts used up",
+			fails:    5,
+			failErr:  errFlaky,
+			err:      errFlaky,
+			attempts: 3,
+		},
+		{
+			name:     "not retryable",
+			opts:     []retryOpt{WithRetryable(func(err error) bool { return !errors.Is(err, errFatal) })},
+			fails:    5,
+			failErr:  errFatal,
+			err:      errFatal,
+			attempts: 1,
+		},
+		{
+			name:     "canceled during backoff",
+			opts:     []retryOpt{WithBackoff(time.Second, time.Second)},
+			fails:    5,
+			failErr:  errFlaky,
+			timeout:  20 * time.Millisecond,
+			err:      context.DeadlineExceeded,
+			attempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := &DefaultLogger{}
+			r := NewRetry(append([]retryOpt{WithBackoff(time.Millisecond, 5*time.Millisecond), WithRetryLogger(log)}, tt.opts...)...)
+
+			c := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				c, cancel = context.WithTimeout(c, tt.timeout)
+				defer cancel()
+			}
+
+			var attempts atomic.Int32
+			err := r.Do(c, func(c context.Context) error {
+				if attempts.Add(1) <= tt.fails {
+					return tt.failErr
+				}
+				return nil
+			})
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, tt.err)
+			}
+			if attempts.Load() != tt.attempts {
+				t.Errorf("%s has wrong attempts %d should %d", t.Name(), attempts.Load(), tt.attempts)
+			}
+			if failed := min(tt.attempts, tt.fails); log.Err() == nil || strings.Count(log.Err().Error(), "[attempt") != int(failed) {
+				t.Errorf("%s has wrong log[%+v]", t.Name(), log.Err())
+			}
+		})
+	}
+
+	fmt.Println(t.Name())
+}
+
+func TestGoFuncRetry(t *testing.T) {
+	log := &DefaultLogger{}
+	r := NewRetry(WithAttempts(2), WithBackoff(time.Millisecond, time.Millisecond))
+	gofn := NewGoFunc(WithLogger(log), WithRetry(r))
+
+	var attempts atomic.Int32
+	err := gofn.Run(context.Background(), func(c context.Context, i int) error {
+		if attempts.Add(1) == 1 {
+			return errors.New("flaky")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+	}
+	if attempts.Load() != 2 {
+		t.Errorf("%s has wrong attempts %d should %d", t.Name(), attempts.Load(), 2)
+	}
+	if log.Err() == nil || !strings.Contains(log.Err().Error(), "[0th][attempt 1/2]") {
+		t.Errorf("%s has wrong log[%+v]", t.Name(), log.Err())
+	}
+
+	fmt.Println(t.Name())
+}
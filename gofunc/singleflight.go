@@ -0,0 +1,105 @@
+package gofunc
+
+/*
+ * @abstract coalescing the concurrent calls of the same key
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/neo532/gokit/errorx"
+)
+
+type flightCall[V any] struct {
+	done     chan struct{}
+	val      V
+	err      error
+	expireAt time.Time
+}
+
+// Group coalesces the concurrent calls of the same key into one,
+// and reuses the successful result within the ttl for the later callers.
+type Group[K comparable, V any] struct {
+	ttl time.Duration
+
+	lock  sync.Mutex
+	calls map[K]*flightCall[V]
+}
+
+// NewGroup returns a instance of Group, 0 ttl means the result is only shared by the concurrent callers.
+func NewGroup[K comparable, V any](ttl time.Duration) *Group[K, V] {
+	return &Group[K, V]{
+		ttl:   ttl,
+		calls: make(map[K]*flightCall[V]),
+	}
+}
+
+// Do returns the result of fn for key, shared is true when it is from the call of others.
+// fn runs with the values of the first caller's context but without its cancellation,
+// so one caller returning on its context done does not cancel the others.
+// A panic of fn is returned to all callers as an error with the stack.
+func (g *Group[K, V]) Do(c context.Context, key K, fn func(c context.Context) (V, error)) (v V, shared bool, err error) {
+	g.lock.Lock()
+	call, ok := g.calls[key]
+	if ok && !call.expireAt.IsZero() && !time.Now().Before(call.expireAt) {
+		delete(g.calls, key)
+		ok = false
+	}
+	if !ok {
+		call = &flightCall[V]{done: make(chan struct{})}
+		g.calls[key] = call
+		go g.call(context.WithoutCancel(c), key, call, fn)
+	}
+	g.lock.Unlock()
+
+	select {
+	case <-call.done:
+		return call.val, ok, call.err
+	case <-c.Done():
+		err = c.Err()
+		return
+	}
+}
+
+// Forget drops the running call or the result of key, the next Do calls fn again.
+func (g *Group[K, V]) Forget(key K) {
+	g.lock.Lock()
+	delete(g.calls, key)
+	g.lock.Unlock()
+}
+
+func (g *Group[K, V]) call(c context.Context, key K, call *flightCall[V], fn func(c context.Context) (V, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			call.err = errorx.New("[singleflight][%+v][%s]", r, string(debug.Stack()))
+		}
+
+		g.lock.Lock()
+		if g.ttl <= 0 || call.err != nil {
+			g.remove(key, call)
+		} else {
+			call.expireAt = time.Now().Add(g.ttl)
+			time.AfterFunc(g.ttl, func() {
+				g.lock.Lock()
+				defer g.lock.Unlock()
+				g.remove(key, call)
+			})
+		}
+		g.lock.Unlock()
+		close(call.done)
+	}()
+
+	call.val, call.err = fn(c)
+}
+
+// remove drops call of key unless it has been replaced.
+func (g *Group[K, V]) remove(key K, call *flightCall[V]) {
+	if g.calls[key] == call {
+		delete(g.calls, key)
+	}
+}
@@ -0,0 +1,124 @@
+package gofunc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroup(t *testing.T) {
+	g := NewGroup[string, int](0)
+	c := context.Background()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fn := func(c context.Context) (int, error) {
+		calls.Add(1)
+		<-release
+		return 1, nil
+	}
+
+	l := 10
+	var wg sync.WaitGroup
+	wg.Add(l)
+	var shared atomic.Int32
+	for i := 0; i < l; i++ {
+		go func() {
+			defer wg.Done()
+			v, s, err := g.Do(c, "key", fn)
+			if err != nil || v != 1 {
+				t.Errorf("%s has wrong %d,%+v", t.Name(), v, err)
+			}
+			if s {
+				shared.Add(1)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 || shared.Load() != int32(l-1) {
+		t.Errorf("%s has wrong calls %d, shared %d", t.Name(), calls.Load(), shared.Load())
+	}
+
+	// no ttl, called again.
+	if _, s, _ := g.Do(c, "key", fn); s || calls.Load() != 2 {
+		t.Errorf("%s has wrong calls %d, shared %v", t.Name(), calls.Load(), s)
+	}
+
+	fmt.Println(t.Name())
+}
+
+func TestGroupCancel(t *testing.T) {
+	g := NewGroup[string, int](0)
+
+	release := make(chan struct{})
+	var canceled atomic.Bool
+	fn := func(c context.Context) (int, error) {
+		select {
+		case <-release:
+			return 1, nil
+		case <-c.Done():
+			canceled.Store(true)
+			return 0, c.Err()
+		}
+	}
+
+	// the first caller gives up.
+	c, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, _, err := g.Do(c, "key", fn); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, context.DeadlineExceeded)
+	}
+
+	rst := make(chan int)
+	go func() {
+		v, _, _ := g.Do(context.Background(), "key", fn)
+		rst <- v
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	if v := <-rst; v != 1 || canceled.Load() {
+		t.Errorf("%s has wrong %d, canceled %v", t.Name(), v, canceled.Load())
+	}
+
+	fmt.Println(t.Name())
+}
+
+func TestGroupPanicAndTTL(t *testing.T) {
+	g := NewGroup[int, string](30 * time.Millisecond)
+	c := context.Background()
+
+	if _, _, err := g.Do(c, 1, func(c context.Context) (string, error) {
+		panic("boom")
+	}); err == nil {
+		t.Errorf("%s should have err with panic", t.Name())
+	}
+
+	var calls atomic.Int32
+	fn := func(c context.Context) (string, error) {
+		calls.Add(1)
+		return "v", nil
+	}
+	g.Do(c, 1, fn)
+	if v, s, err := g.Do(c, 1, fn); v != "v" || !s || err != nil || calls.Load() != 1 {
+		t.Errorf("%s has wrong %s,%v,%+v,%d", t.Name(), v, s, err, calls.Load())
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if _, s, _ := g.Do(c, 1, fn); s || calls.Load() != 2 {
+		t.Errorf("%s has wrong calls %d after ttl", t.Name(), calls.Load())
+	}
+
+	g.Forget(1)
+	if _, s, _ := g.Do(c, 1, fn); s || calls.Load() != 3 {
+		t.Errorf("%s has wrong calls %d after forget", t.Name(), calls.Load())
+	}
+
+	fmt.Println(t.Name())
+}
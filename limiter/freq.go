@@ -9,29 +9,124 @@ package limiter
 
 import (
 	"context"
+	"errors"
+	"math/rand"
 	"strconv"
 	"time"
 )
 
-// args:1 keyName 10
-var incrLuaScript = `
-local key=KEYS[1]
-local expire=ARGV[1]
-local incr=redis.call('INCR', key)
-if(incr~=1) then
-return incr
+// args:n keyName... nowMs op member [mode expireSeconds times]...
+var freqLuaScript = `
+local now=tonumber(ARGV[1])
+local op=tonumber(ARGV[2])
+local member=ARGV[3]
+local pass=1
+local counts={}
+for i, key in ipairs(KEYS) do
+local mode=ARGV[3*i+1]
+local window=tonumber(ARGV[3*i+2])*1000
+local times=tonumber(ARGV[3*i+3])
+local count=0
+if(mode=='` + ModeSlidingLog + `') then
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now-window)
+count=redis.call('ZCARD', key)
+elseif(mode=='` + ModeSlidingCounter + `') then
+local cur=math.floor(now/window)
+local weight=1-(now%window)/window
+local curCount=tonumber(redis.call('HGET', key, tostring(cur)) or '0')
+local preCount=tonumber(redis.call('HGET', key, tostring(cur-1)) or '0')
+count=math.floor(preCount*weight)+curCount
+else
+count=tonumber(redis.call('GET', key) or '0')
 end
-local rst=redis.call('EXPIRE', key, expire)
-if(rst~=1) then
-return -1
+counts[i]=count
+if(op==` + strconv.Itoa(opIncrCheck) + ` and count>=times) then
+pass=0
 end
-return incr
+end
+if(op==` + strconv.Itoa(opQuota) + `) then
+for i, key in ipairs(KEYS) do
+local ttl=redis.call('PTTL', key)
+if(ARGV[3*i+1]=='` + ModeSlidingLog + `') then
+local oldest=redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if(oldest[2]) then
+ttl=math.ceil(tonumber(oldest[2])+tonumber(ARGV[3*i+2])*1000-now)
+end
+end
+counts[#KEYS+i]=ttl
+end
+end
+if(pass==0 or op==` + strconv.Itoa(opGet) + ` or op==` + strconv.Itoa(opQuota) + `) then
+return {pass, unpack(counts)}
+end
+for i, key in ipairs(KEYS) do
+local mode=ARGV[3*i+1]
+local expire=tonumber(ARGV[3*i+2])
+local window=expire*1000
+if(op==` + strconv.Itoa(opDecr) + `) then
+if(counts[i]>0) then
+counts[i]=counts[i]-1
+if(mode=='` + ModeSlidingLog + `') then
+redis.call('ZREMRANGEBYRANK', key, -1, -1)
+elseif(mode=='` + ModeSlidingCounter + `') then
+local cur=math.floor(now/window)
+if(tonumber(redis.call('HGET', key, tostring(cur)) or '0')>0) then
+redis.call('HINCRBY', key, tostring(cur), -1)
+else
+redis.call('HINCRBY', key, tostring(cur-1), -1)
+end
+else
+redis.call('DECR', key)
+end
+end
+else
+counts[i]=counts[i]+1
+if(mode=='` + ModeSlidingLog + `') then
+redis.call('ZADD', key, now, member)
+redis.call('PEXPIRE', key, window)
+elseif(mode=='` + ModeSlidingCounter + `') then
+local cur=math.floor(now/window)
+redis.call('HINCRBY', key, tostring(cur), 1)
+redis.call('HDEL', key, tostring(cur-2))
+redis.call('PEXPIRE', key, window*2)
+elseif(redis.call('INCR', key)==1) then
+redis.call('EXPIRE', key, expire)
+end
+end
+end
+return {pass, unpack(counts)}
+`
+
+// args:n keyName...
+var resetLuaScript = `
+return redis.call('DEL', unpack(KEYS))
 `
 
 const (
-	DurationToday     = "today"
-	DurationThisWeek  = "thisWeek"
-	DurationThisMonth = "thisMonth"
+	opGet = iota
+	opIncr
+	opIncrCheck
+	opDecr
+	opQuota
+)
+
+const (
+	// ModeFixedWindow counts in the fixed window described by Duri.
+	ModeFixedWindow = ""
+	// ModeSlidingLog counts exactly in the last Duri seconds by a sorted set.
+	ModeSlidingLog = "slidingLog"
+	// ModeSlidingCounter estimates the count in the last Duri seconds
+	// by weighting the previous window's counter.
+	ModeSlidingCounter = "slidingCounter"
+)
+
+const (
+	DurationThisMinute = "thisMinute"
+	DurationThisHour   = "thisHour"
+	DurationToday      = "today"
+	DurationThisWeek   = "thisWeek" // ISO week
+	DurationThisMonth  = "thisMonth"
+	DurationThisYear   = "thisYear"
 )
 
 // IFreqDb is the interface for FreqRule.
@@ -42,11 +137,22 @@ type IFreqDb interface {
 
 // FreqRule is the instance for FreqRule.
 type FreqRule struct {
-	Duri  string //3|today
+	Duri  string //3|thisMinute|thisHour|today|thisWeek|thisMonth|thisYear
 	Times int64
 
 	Timezone *time.Location
 	N        int
+
+	// Mode is one of ModeFixedWindow, ModeSlidingLog and ModeSlidingCounter.
+	// The sliding modes only accept seconds in Duri.
+	Mode string
+}
+
+type freqKey struct {
+	key    string
+	expire int64
+	times  int64
+	mode   string
 }
 
 // Freq is the instance for FreqRule.
@@ -71,9 +177,18 @@ func (f *Freq) Timezone(timezone string) (err error) {
 
 // Get return the last count.
 func (f *Freq) Get(c context.Context, pre string, rule ...FreqRule) (ts int64, err error) {
-	f.freq(pre, rule, func(key string, expire, times int64) bool {
+	f.freq(pre, rule, func(k freqKey) bool {
+		if k.mode != ModeFixedWindow {
+			var counts []int64
+			if _, counts, err = f.eval(c, opGet, []freqKey{k}); err != nil {
+				return false
+			}
+			ts = counts[0]
+			return true
+		}
+
 		var tsOri string
-		if tsOri, err = f.db.Get(c, key); err != nil {
+		if tsOri, err = f.db.Get(c, k.key); err != nil {
 			return false
 		}
 
@@ -85,13 +200,21 @@ func (f *Freq) Get(c context.Context, pre string, rule ...FreqRule) (ts int64, e
 
 // Check checks the count only.
 func (f *Freq) Check(c context.Context, pre string, rule ...FreqRule) (bRst bool, err error) {
-	bRst = f.freq(pre, rule, func(key string, expire, times int64) bool {
+	bRst = f.freq(pre, rule, func(k freqKey) bool {
+		if k.mode != ModeFixedWindow {
+			var counts []int64
+			if _, counts, err = f.eval(c, opGet, []freqKey{k}); err != nil || counts[0] > k.times-1 {
+				return false
+			}
+			return true
+		}
+
 		var tsOri string
-		if tsOri, err = f.db.Get(c, key); err != nil {
+		if tsOri, err = f.db.Get(c, k.key); err != nil {
 			return false
 		}
 
-		if ts, err := strconv.ParseInt(tsOri, 10, 64); err != nil || ts > times-1 {
+		if ts, err := strconv.ParseInt(tsOri, 10, 64); err != nil || ts > k.times-1 {
 			return false
 		}
 		return true
@@ -99,93 +222,178 @@ func (f *Freq) Check(c context.Context, pre string, rule ...FreqRule) (bRst bool
 	return
 }
 
-// Incr increments the count only.
+// Incr increments the count of all rules only.
 func (f *Freq) Incr(c context.Context, pre string, rule ...FreqRule) (bRst bool, err error) {
-	bRst = f.freq(pre, rule, func(key string, expire, times int64) bool {
-		var tsOri any
-		if tsOri, err = f.db.Eval(c, incrLuaScript, []string{key}, []any{expire}); err != nil {
-			return false
-		}
-
-		if ts, ok := tsOri.(int64); !ok || ts == -1 {
-			return false
-		}
-		return true
-	})
+	ks, ok := f.keys(pre, rule)
+	if !ok {
+		return
+	}
+	bRst, _, err = f.eval(c, opIncr, ks)
 	return
 }
 
-// IncrCheck increments and checks the count.
+// IncrCheck increments the count of all rules only when every rule passes the check.
+// The counts are left untouched if any rule rejects.
 func (f *Freq) IncrCheck(c context.Context, pre string, rule ...FreqRule) (bRst bool, err error) {
-	bRst = f.freq(pre, rule, func(key string, expire, times int64) bool {
-		var tsOri any
-		if tsOri, err = f.db.Eval(c, incrLuaScript, []string{key}, []any{expire}); err != nil {
-			return false
-		}
+	ks, ok := f.keys(pre, rule)
+	if !ok {
+		return
+	}
+	bRst, _, err = f.eval(c, opIncrCheck, ks)
+	return
+}
 
-		if ts, ok := tsOri.(int64); !ok || ts == -1 || ts > times {
+// Decr decrements the count of all rules to refund the quota, such as the downstream fails.
+// The count never goes below zero.
+func (f *Freq) Decr(c context.Context, pre string, rule ...FreqRule) (bRst bool, err error) {
+	ks, ok := f.keys(pre, rule)
+	if !ok {
+		return
+	}
+	bRst, _, err = f.eval(c, opDecr, ks)
+	return
+}
+
+// Reset removes the count of all rules.
+func (f *Freq) Reset(c context.Context, pre string, rule ...FreqRule) (err error) {
+	ks, ok := f.keys(pre, rule)
+	if !ok || len(ks) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(ks))
+	for _, k := range ks {
+		keys = append(keys, k.key)
+	}
+	_, err = f.db.Eval(c, resetLuaScript, keys, []any{})
+	return
+}
+
+// eval runs the op on all keys in one script and returns the counts after the op,
+// followed by the ttl in milliseconds of all keys for opQuota.
+func (f *Freq) eval(c context.Context, op int, ks []freqKey) (bRst bool, counts []int64, err error) {
+	if len(ks) == 0 {
+		bRst = true
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	member := strconv.FormatInt(now, 10) + "_" + strconv.FormatInt(rand.Int63(), 36)
+
+	keys := make([]string, 0, len(ks))
+	args := make([]any, 0, 3+len(ks)*3)
+	args = append(args, now, op, member)
+	for _, k := range ks {
+		keys = append(keys, k.key)
+		args = append(args, k.mode, k.expire, k.times)
+	}
+
+	var rst any
+	if rst, err = f.db.Eval(c, freqLuaScript, keys, args); err != nil {
+		return
+	}
+
+	vs, ok := rst.([]any)
+	if !ok || len(vs) < len(ks)+1 {
+		err = errors.New("limiter: invalid reply of freq script")
+		return
+	}
+	counts = make([]int64, 0, len(vs)-1)
+	for _, v := range vs[1:] {
+		i, _ := v.(int64)
+		counts = append(counts, i)
+	}
+	pass, _ := vs[0].(int64)
+	bRst = pass == 1
+	return
+}
+
+func (f *Freq) freq(pre string, ruleList []FreqRule, fn func(k freqKey) bool) bool {
+	ks, ok := f.keys(pre, ruleList)
+	if !ok {
+		return false
+	}
+	for _, k := range ks {
+		if false == fn(k) {
 			return false
 		}
-		return true
-	})
-	return
+	}
+	return true
 }
 
-func (f *Freq) freq(pre string, ruleList []FreqRule, fn func(key string, expire, times int64) bool) bool {
+// keys returns the keys of rules, it is false if any rule is invalid.
+func (f *Freq) keys(pre string, ruleList []FreqRule) (ks []freqKey, ok bool) {
 	prekey := "freq:" + pre + ":"
+	ks = make([]freqKey, 0, len(ruleList))
 	for _, r := range ruleList {
+		if r.Mode == ModeSlidingLog || r.Mode == ModeSlidingCounter {
+			expire, err := strconv.ParseInt(r.Duri, 10, 64)
+			if err != nil || expire <= 0 {
+				return
+			}
+			ks = append(ks, freqKey{key: prekey + r.Mode + r.Duri, expire: expire, times: r.Times, mode: r.Mode})
+			continue
+		}
+		if r.Mode != ModeFixedWindow {
+			return
+		}
+
+		if r.Timezone == nil {
+			r.Timezone = f.tz
+		}
+		now := time.Now().In(r.Timezone)
+
 		var key string
 		var expire int64
 		switch r.Duri {
+		case DurationThisMinute:
+			key = prekey + DurationThisMinute + now.Format("2006_01_02_15_04")
+			expire = time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute()+1, 0, 0, r.Timezone).Unix() - now.Unix()
+
+		case DurationThisHour:
+			key = prekey + DurationThisHour + now.Format("2006_01_02_15")
+			expire = time.Date(now.Year(), now.Month(), now.Day(), now.Hour()+1, 0, 0, 0, r.Timezone).Unix() - now.Unix()
+
 		case DurationToday:
-			if r.Timezone == nil {
-				r.Timezone = f.tz
-			}
-			now := time.Now().In(r.Timezone)
 			tomorrowFirst := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, r.Timezone)
 			key = prekey + now.Format("2006_01_02")
-			expire = int64(tomorrowFirst.Sub(now).Seconds())
+			expire = tomorrowFirst.Unix() - now.Unix()
 			if n := r.N - 1; n > 0 {
 				key += "_" + strconv.Itoa(r.N)
 				expire += int64(n) * 86400
 			}
 
 		case DurationThisWeek:
-			if r.Timezone == nil {
-				r.Timezone = f.tz
-			}
-			now := time.Now().In(r.Timezone)
-			w, s := f.weekOfYear(now, r.Timezone)
-			key = prekey + DurationThisWeek + strconv.Itoa(w)
+			y, w, s := f.weekOfYear(now, r.Timezone)
+			key = prekey + DurationThisWeek + strconv.Itoa(y) + "_" + strconv.Itoa(w)
 			expire = s
 
 		case DurationThisMonth:
-			if r.Timezone == nil {
-				r.Timezone = f.tz
-			}
-			now := time.Now().In(r.Timezone)
-			key = prekey + DurationThisMonth + strconv.Itoa(int(now.Month()))
+			key = prekey + DurationThisMonth + now.Format("2006_01")
 			expire = time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, r.Timezone).Unix() - now.Unix()
 
+		case DurationThisYear:
+			key = prekey + DurationThisYear + now.Format("2006")
+			expire = time.Date(now.Year()+1, 1, 1, 0, 0, 0, 0, r.Timezone).Unix() - now.Unix()
+
 		default:
 			var err error
 			key = prekey + r.Duri
 			expire, err = strconv.ParseInt(r.Duri, 10, 64)
 			if nil != err {
-				return false
+				return
 			}
 		}
-		if false == fn(key, expire, r.Times) {
-			return false
-		}
+		ks = append(ks, freqKey{key: key, expire: expire, times: r.Times})
 	}
-	return true
+	ok = true
+	return
 }
 
-func (f *Freq) weekOfYear(t time.Time, tz *time.Location) (weekOfYear int, remainSeconds int64) {
+// weekOfYear returns the ISO year and week of t, and the seconds to the next Monday.
+func (f *Freq) weekOfYear(t time.Time, tz *time.Location) (year, weekOfYear int, remainSeconds int64) {
 	maxWeekDays := 7
 
-	_, weekOfYear = t.ISOWeek()
+	year, weekOfYear = t.ISOWeek()
 
 	dayOfWeek := int(t.Weekday())
 	if dayOfWeek == 0 {
@@ -0,0 +1,99 @@
+package limiter
+
+/*
+ * @abstract quota introspection of Freq
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Quota is the usage of a FreqRule.
+type Quota struct {
+	Rule      FreqRule
+	Used      int64
+	Limit     int64
+	Remaining int64
+	// Reset is the time to the reset, the ttl of the key for ModeFixedWindow,
+	// the time the oldest hit leaves the window for ModeSlidingLog,
+	// and an upper bound for ModeSlidingCounter, the time both windows are expired.
+	Reset   time.Duration
+	ResetAt time.Time
+}
+
+// Quota returns the usage of every rule without counting.
+func (f *Freq) Quota(c context.Context, pre string, rule ...FreqRule) (qs []Quota, err error) {
+	ks, ok := f.keys(pre, rule)
+	if !ok {
+		err = errors.New("limiter: invalid freq rule")
+		return
+	}
+
+	var counts []int64
+	if _, counts, err = f.eval(c, opQuota, ks); err != nil {
+		return
+	}
+	if len(ks) > 0 && len(counts) != len(ks)*2 {
+		err = errors.New("limiter: invalid reply of freq script")
+		return
+	}
+
+	now := time.Now()
+	qs = make([]Quota, 0, len(ks))
+	for i, k := range ks {
+		q := Quota{
+			Rule:      rule[i],
+			Used:      counts[i],
+			Limit:     k.times,
+			Remaining: k.times - counts[i],
+			Reset:     time.Duration(counts[len(ks)+i]) * time.Millisecond,
+		}
+		if q.Remaining < 0 {
+			q.Remaining = 0
+		}
+		// no key or no ttl, the window starts from now.
+		if q.Reset < 0 {
+			q.Reset = time.Duration(k.expire) * time.Second
+		}
+		q.ResetAt = now.Add(q.Reset)
+		qs = append(qs, q)
+	}
+	return
+}
+
+// QuotaHeader returns the X-RateLimit-* and RateLimit-* response headers of the most restrictive quota,
+// which has the least remaining and the latest reset.
+func QuotaHeader(qs ...Quota) (h http.Header) {
+	h = http.Header{}
+	if len(qs) == 0 {
+		return
+	}
+
+	q := qs[0]
+	for _, o := range qs[1:] {
+		if o.Remaining < q.Remaining || (o.Remaining == q.Remaining && o.Reset > q.Reset) {
+			q = o
+		}
+	}
+
+	limit := strconv.FormatInt(q.Limit, 10)
+	remaining := strconv.FormatInt(q.Remaining, 10)
+	reset := strconv.FormatInt(int64((q.Reset+time.Second-1)/time.Second), 10)
+
+	h.Set("X-RateLimit-Limit", limit)
+	h.Set("X-RateLimit-Remaining", remaining)
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(q.ResetAt.Unix(), 10))
+	h.Set("RateLimit-Limit", limit)
+	h.Set("RateLimit-Remaining", remaining)
+	h.Set("RateLimit-Reset", reset)
+	if q.Remaining == 0 {
+		h.Set("Retry-After", reset)
+	}
+	return
+}
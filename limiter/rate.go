@@ -0,0 +1,185 @@
+package limiter
+
+/*
+ * @abstract rate shaping by token bucket and GCRA
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// args:1 keyName nowMs intervalMs burst n reserve
+var tokenBucketLuaScript = `
+local key=KEYS[1]
+local now=tonumber(ARGV[1])
+local interval=tonumber(ARGV[2])
+local burst=tonumber(ARGV[3])
+local n=tonumber(ARGV[4])
+local reserve=tonumber(ARGV[5])
+local data=redis.call('HMGET', key, 'tokens', 'ts')
+local tokens=tonumber(data[1])
+local ts=tonumber(data[2])
+if(tokens==nil or ts==nil) then
+tokens=burst
+ts=now
+end
+if(now>ts) then
+tokens=math.min(burst, tokens+(now-ts)/interval)
+ts=now
+end
+local retry=0
+if(tokens<n) then
+retry=math.ceil((n-tokens)*interval)
+if(reserve==0) then
+return {0, retry}
+end
+end
+tokens=tokens-n
+redis.call('HMSET', key, 'tokens', tokens, 'ts', ts)
+redis.call('PEXPIRE', key, math.max(1, math.ceil((burst-tokens)*interval)))
+return {1, retry}
+`
+
+// args:1 keyName nowMs intervalMs burst n reserve
+var gcraLuaScript = `
+local key=KEYS[1]
+local now=tonumber(ARGV[1])
+local interval=tonumber(ARGV[2])
+local burst=tonumber(ARGV[3])
+local n=tonumber(ARGV[4])
+local reserve=tonumber(ARGV[5])
+local tat=tonumber(redis.call('GET', key) or now)
+if(tat<now) then
+tat=now
+end
+local newTat=tat+n*interval
+local allowAt=newTat-burst*interval
+local retry=0
+if(allowAt>now) then
+retry=math.ceil(allowAt-now)
+if(reserve==0) then
+return {0, retry}
+end
+end
+redis.call('SET', key, newTat, 'PX', math.max(1, math.ceil(newTat-now)))
+return {1, retry}
+`
+
+const (
+	AlgorithmGCRA        = "gcra"
+	AlgorithmTokenBucket = "tokenBucket"
+)
+
+var rateLuaScripts = map[string]string{
+	AlgorithmGCRA:        gcraLuaScript,
+	AlgorithmTokenBucket: tokenBucketLuaScript,
+}
+
+var (
+	ErrRateLimit       = errors.New("limiter: invalid rate limit")
+	ErrRateExceedBurst = errors.New("limiter: n exceeds burst")
+)
+
+// RateLimit is the rule for Rate, it allows Rate events per Period with bursts of at most Burst events.
+type RateLimit struct {
+	Rate   int64
+	Period time.Duration
+	Burst  int64
+}
+
+// PerSecond returns a RateLimit allowing rate events per second.
+func PerSecond(rate, burst int64) RateLimit {
+	return RateLimit{Rate: rate, Period: time.Second, Burst: burst}
+}
+
+// PerMinute returns a RateLimit allowing rate events per minute.
+func PerMinute(rate, burst int64) RateLimit {
+	return RateLimit{Rate: rate, Period: time.Minute, Burst: burst}
+}
+
+// Rate is the instance for rate shaping.
+type Rate struct {
+	db        IFreqDb
+	algorithm string
+}
+
+// NewRate returns a instance of Rate, using GCRA by default.
+func NewRate(d IFreqDb) *Rate {
+	return &Rate{
+		db:        d,
+		algorithm: AlgorithmGCRA,
+	}
+}
+
+// Algorithm sets the algorithm, AlgorithmGCRA or AlgorithmTokenBucket.
+func (r *Rate) Algorithm(a string) *Rate {
+	r.algorithm = a
+	return r
+}
+
+// Allow reports whether an event may happen now.
+func (r *Rate) Allow(c context.Context, key string, limit RateLimit) (bRst bool, retryAfter time.Duration, err error) {
+	return r.AllowN(c, key, limit, 1)
+}
+
+// AllowN reports whether n events may happen now,
+// retryAfter is the time to wait for when it is not allowed.
+func (r *Rate) AllowN(c context.Context, key string, limit RateLimit, n int64) (bRst bool, retryAfter time.Duration, err error) {
+	return r.take(c, key, limit, n, false)
+}
+
+// Reserve takes n events whatever and returns the delay to wait for before they may happen.
+func (r *Rate) Reserve(c context.Context, key string, limit RateLimit, n int64) (delay time.Duration, err error) {
+	_, delay, err = r.take(c, key, limit, n, true)
+	return
+}
+
+func (r *Rate) take(c context.Context, key string, limit RateLimit, n int64, reserve bool) (bRst bool, retry time.Duration, err error) {
+	script, ok := rateLuaScripts[r.algorithm]
+	if !ok || limit.Rate <= 0 || limit.Period <= 0 || limit.Burst <= 0 || n <= 0 {
+		err = ErrRateLimit
+		return
+	}
+	if n > limit.Burst {
+		err = ErrRateExceedBurst
+		return
+	}
+
+	interval := float64(limit.Period.Milliseconds()) / float64(limit.Rate)
+	isReserve := 0
+	if reserve {
+		isReserve = 1
+	}
+
+	var rst any
+	if rst, err = r.db.Eval(
+		c,
+		script,
+		[]string{"rate:" + r.algorithm + ":" + key},
+		[]any{
+			time.Now().UnixMilli(),
+			strconv.FormatFloat(interval, 'f', -1, 64),
+			limit.Burst,
+			n,
+			isReserve,
+		},
+	); err != nil {
+		return
+	}
+
+	vs, ok := rst.([]any)
+	if !ok || len(vs) != 2 {
+		err = errors.New("limiter: invalid reply of rate script")
+		return
+	}
+	allowed, _ := vs[0].(int64)
+	ms, _ := vs[1].(int64)
+	bRst = allowed == 1
+	retry = time.Duration(ms) * time.Millisecond
+	return
+}
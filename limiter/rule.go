@@ -0,0 +1,117 @@
+package limiter
+
+/*
+ * @abstract parser of the compact FreqRule
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var calendarDurations = map[string]bool{
+	DurationThisMinute: true,
+	DurationThisHour:   true,
+	DurationToday:      true,
+	DurationThisWeek:   true,
+	DurationThisMonth:  true,
+	DurationThisYear:   true,
+}
+
+// ParseFreqRules parses the compact rules separated by comma into []FreqRule,
+// such as "5/1m,100/today@Asia/Shanghai,10/30s~slidingLog".
+//
+// Each rule is times/duration[~mode][@timezone], the duration is
+// a calendar duration like today and thisMonth, seconds like 30,
+// or a length with the unit s, m, h and d like 1m and 7d.
+func ParseFreqRules(s string) (rules []FreqRule, err error) {
+	for _, o := range strings.Split(s, ",") {
+		if o = strings.TrimSpace(o); o == "" {
+			continue
+		}
+		var r FreqRule
+		if r, err = ParseFreqRule(o); err != nil {
+			return
+		}
+		rules = append(rules, r)
+	}
+	return
+}
+
+// ParseFreqRule parses one compact rule like "100/today@Asia/Shanghai".
+func ParseFreqRule(s string) (r FreqRule, err error) {
+	rule := strings.TrimSpace(s)
+
+	if i := strings.Index(rule, "@"); i >= 0 {
+		if r.Timezone, err = time.LoadLocation(strings.TrimSpace(rule[i+1:])); err != nil {
+			err = fmt.Errorf("limiter: invalid timezone of rule %q: %w", s, err)
+			return
+		}
+		rule = rule[:i]
+	}
+
+	if i := strings.Index(rule, "~"); i >= 0 {
+		r.Mode = strings.TrimSpace(rule[i+1:])
+		rule = rule[:i]
+		if r.Mode != ModeSlidingLog && r.Mode != ModeSlidingCounter {
+			err = fmt.Errorf("limiter: invalid mode of rule %q", s)
+			return
+		}
+	}
+
+	times, duri, ok := strings.Cut(rule, "/")
+	if !ok {
+		err = fmt.Errorf("limiter: invalid rule %q, should be times/duration", s)
+		return
+	}
+	if r.Times, err = strconv.ParseInt(strings.TrimSpace(times), 10, 64); err != nil || r.Times <= 0 {
+		err = fmt.Errorf("limiter: invalid times of rule %q", s)
+		return
+	}
+	if r.Duri, err = parseDuri(strings.TrimSpace(duri)); err != nil {
+		err = fmt.Errorf("limiter: invalid duration of rule %q: %w", s, err)
+		return
+	}
+	if r.Mode != ModeFixedWindow && calendarDurations[r.Duri] {
+		err = fmt.Errorf("limiter: sliding mode of rule %q only accepts seconds", s)
+	}
+	return
+}
+
+// parseDuri returns the calendar duration or the seconds.
+func parseDuri(s string) (duri string, err error) {
+	if calendarDurations[s] {
+		return s, nil
+	}
+
+	var seconds int64
+	switch {
+	case s == "":
+		err = fmt.Errorf("empty duration")
+	case strings.HasSuffix(s, "d"):
+		var days int64
+		if days, err = strconv.ParseInt(strings.TrimSuffix(s, "d"), 10, 64); err == nil {
+			seconds = days * 86400
+		}
+	case strings.Trim(s, "0123456789") == "":
+		seconds, err = strconv.ParseInt(s, 10, 64)
+	default:
+		var d time.Duration
+		if d, err = time.ParseDuration(s); err == nil {
+			seconds = int64(d / time.Second)
+		}
+	}
+	if err != nil {
+		return
+	}
+	if seconds <= 0 {
+		err = fmt.Errorf("duration %q is less than 1s", s)
+		return
+	}
+	duri = strconv.FormatInt(seconds, 10)
+	return
+}
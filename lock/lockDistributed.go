@@ -10,6 +10,8 @@ package lock
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math/rand"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,22 +19,36 @@ import (
 
 const evalOk = "ok"
 
-// args:1 keyName code 10
+var (
+	// ErrNotAcquired is returned when the lock is held by others.
+	ErrNotAcquired = errors.New("lock: not acquired")
+	// ErrNotOwner is returned when the lock is expired or held by others on unlocking.
+	ErrNotOwner = errors.New("lock: not owner")
+	// ErrTimeout is returned when the lock is not acquired within wait, it also matches ErrNotAcquired.
+	ErrTimeout = errors.New("lock: timeout")
+	// ErrNoInstance is returned when Redlock has no instance.
+	ErrNoInstance = errors.New("lock: no instance")
+	// ErrNoPermit is returned when Semaphore has no permit.
+	ErrNoPermit = errors.New("lock: no permit")
+)
+
+// args:1 keyName code 10000
 var lockLuaScript = `
 local key=KEYS[1] 
 local code=ARGV[1]
 local expire=ARGV[2]
-local rst=redis.call('SET', key, code, 'EX', expire, 'NX')
+local rst=redis.call('SET', key, code, 'PX', expire, 'NX')
 if(rst==false) then
 	return 'set fail'
 end
 return '` + evalOk + `'
 `
 
-// args:1 keyName code
+// args:1 keyName code [channel]
 var unlockLuaScript = `
 local key=KEYS[1]
 local code=ARGV[1] 
+local channel=ARGV[2]
 local value=redis.call('GET', key)
 if(value==false) then
 	return 'get fail'
@@ -44,6 +60,9 @@ local rst=redis.call('DEL', key)
 if(rst==0) then
 	return 'del fail' 
 end
+if(channel) then
+	redis.call('PUBLISH', channel, key)
+end
 return '` + evalOk + `'
 `
 
@@ -52,18 +71,27 @@ type IDistributedLockDb interface {
 	Eval(c context.Context, cmd string, keys []string, args []any) (rst any, err error)
 }
 
+// IDistributedLockSubscriber is the optional interface for DistributedLock's db,
+// the waiters are woken up by the message published on unlocking instead of polling.
+type IDistributedLockSubscriber interface {
+	Subscribe(c context.Context, channel string) (msgs <-chan string, cancel func(), err error)
+}
+
 // DistributedLock is the instance for DistributedLock.
 type DistributedLock struct {
-	db       IDistributedLockDb
-	duration time.Duration
-	genCode  func() (s string, err error)
+	db          IDistributedLockDb
+	duration    time.Duration
+	maxDuration time.Duration
+	notify      bool
+	genCode     func() (s string, err error)
 }
 
 // NewDistributedLock returns the instance for Lock.
 func NewDistributedLock(d IDistributedLockDb) *DistributedLock {
 	return &DistributedLock{
-		db:       d,
-		duration: time.Duration(50) * time.Millisecond,
+		db:          d,
+		duration:    time.Duration(50) * time.Millisecond,
+		maxDuration: time.Second,
 		genCode: func() (s string, err error) {
 			var id uuid.UUID
 			if id, err = uuid.NewRandom(); err == nil {
@@ -80,26 +108,33 @@ func (l *DistributedLock) GenUniqCodeFn(fn func() (s string, err error)) *Distri
 	return l
 }
 
-// Duration sets the duration on lock.
+// Duration sets the first duration on lock, it doubles with jitter on every retry.
 func (l *DistributedLock) Duration(d time.Duration) *DistributedLock {
 	l.duration = d
 	return l
 }
 
+// MaxDuration sets the max duration on lock.
+func (l *DistributedLock) MaxDuration(d time.Duration) *DistributedLock {
+	l.maxDuration = d
+	return l
+}
+
+// Notify publishes on unlocking and wakes up the waiters at once,
+// the db should implement IDistributedLockSubscriber.
+func (l *DistributedLock) Notify(b bool) *DistributedLock {
+	l.notify = b
+	return l
+}
+
 // UnLock unlocks.
 func (l *DistributedLock) UnLock(c context.Context, key string, code string) (err error) {
 	key = getLockKey(key)
-	var rst any
-	if rst, err = l.db.Eval(c, unlockLuaScript, []string{key}, []any{code}); err != nil {
-		return
+	args := []any{code}
+	if l.notify {
+		args = append(args, key)
 	}
-	e, ok := rst.(string)
-	if ok && e == evalOk {
-		err = nil
-		return
-	}
-	err = errors.New(e)
-	return
+	return replyErr(l.db.Eval(c, unlockLuaScript, []string{key}, args))
 }
 
 // Lock locks and returns the result if locking is successfully.
@@ -110,27 +145,111 @@ func (l *DistributedLock) Lock(c context.Context, key string, expire, wait time.
 	}
 	key = getLockKey(key)
 
+	err = l.acquire(c, key, wait, func() (any, error) {
+		return l.db.Eval(c, lockLuaScript, []string{key}, []any{code, expire.Milliseconds()})
+	})
+	return
+}
+
+// TryLock locks without waiting, it returns ErrNotAcquired when the lock is held by others.
+func (l *DistributedLock) TryLock(c context.Context, key string, expire time.Duration) (code string, err error) {
+	if code, err = l.genCode(); err != nil {
+		return
+	}
+	err = replyErr(l.db.Eval(c, lockLuaScript, []string{getLockKey(key)}, []any{code, expire.Milliseconds()}))
+	return
+}
+
+// acquire runs eval until it replies evalOk, wait is elapsed or c is done.
+// It retries with jittered exponential backoff, or at once when the channel is notified.
+func (l *DistributedLock) acquire(c context.Context, channel string, wait time.Duration, eval func() (rst any, err error)) (err error) {
 	endTs := time.Now().Add(wait)
-	for time.Now().Before(endTs) {
+	if !time.Now().Before(endTs) {
+		err = ErrTimeout
+		return
+	}
+
+	var wake <-chan string
+	subscribed := false
+	backoff := l.duration
+	for {
 		var rst any
-		if rst, err = l.db.Eval(c, lockLuaScript, []string{key}, []any{code, expire.Seconds()}); err != nil {
+		if rst, err = eval(); err != nil {
 			return
 		}
 
-		e, ok := rst.(string)
-		if ok && e == evalOk {
-			err = nil
+		if err = replyErr(rst, nil); err == nil {
 			return
 		}
-		err = errors.New(e)
 
-		time.Sleep(l.duration)
+		remain := time.Until(endTs)
+		if remain <= 0 {
+			err = fmt.Errorf("%w: %w", ErrTimeout, err)
+			return
+		}
+
+		if l.notify && !subscribed {
+			subscribed = true
+			if s, ok := l.db.(IDistributedLockSubscriber); ok {
+				if msgs, cancel, e := s.Subscribe(c, channel); e == nil {
+					defer cancel()
+					wake = msgs
+					// retry at once, the unlocking before subscribing is missed.
+					continue
+				}
+			}
+		}
+
+		d := jitter(backoff)
+		if d > remain {
+			d = remain
+		}
+		t := time.NewTimer(d)
+		select {
+		case <-c.Done():
+			t.Stop()
+			err = c.Err()
+			return
+		case _, ok := <-wake:
+			t.Stop()
+			if !ok {
+				wake = nil
+			}
+		case <-t.C:
+		}
+
+		if backoff *= 2; backoff > l.maxDuration {
+			backoff = max(l.maxDuration, l.duration)
+		}
 	}
+}
 
-	if err == nil {
-		err = errors.New("timeout")
+// jitter returns a random duration in [d/2, d].
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
 	}
-	return
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// replyErr returns the error of the reply of the scripts.
+func replyErr(rst any, err error) error {
+	if err != nil {
+		return err
+	}
+	e, ok := rst.(string)
+	if ok && e == evalOk {
+		return nil
+	}
+	switch e {
+	case "set fail":
+		return ErrNotAcquired
+	case "get fail", "equal fail", "del fail":
+		return fmt.Errorf("%w: %s", ErrNotOwner, e)
+	case "stale token":
+		return ErrStaleToken
+	}
+	return fmt.Errorf("lock: invalid reply %v", rst)
 }
 
 func getLockKey(key string) string {
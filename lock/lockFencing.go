@@ -0,0 +1,69 @@
+package lock
+
+/*
+ * @abstract fencing token of DistributedLock
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrStaleToken is returned when the fencing token is older than the one has written.
+var ErrStaleToken = errors.New("lock: stale token")
+
+// args:2 keyName keyName:token code 10000
+var fencingLockLuaScript = `
+local key=KEYS[1]
+local tkey=KEYS[2]
+local code=ARGV[1]
+local expire=ARGV[2]
+local rst=redis.call('SET', key, code, 'PX', expire, 'NX')
+if(rst==false) then
+	return 'set fail'
+end
+return redis.call('INCR', tkey)
+`
+
+// args:1 fence:resource token
+var fenceLuaScript = `
+local key=KEYS[1]
+local token=tonumber(ARGV[1])
+local last=tonumber(redis.call('GET', key) or '0')
+if(token<last) then
+	return 'stale token'
+end
+redis.call('SET', key, token)
+return '` + evalOk + `'
+`
+
+// LockWithToken locks like Lock and returns a fencing token increasing on every locking of key,
+// the storage refuses the writing with an older token, such as from a holder paused until expired.
+func (l *DistributedLock) LockWithToken(c context.Context, key string, expire, wait time.Duration) (code string, token int64, err error) {
+	if code, err = l.genCode(); err != nil {
+		return
+	}
+	key = getLockKey(key)
+	keys := []string{key, key + ":token"}
+
+	err = l.acquire(c, key, wait, func() (rst any, err error) {
+		if rst, err = l.db.Eval(c, fencingLockLuaScript, keys, []any{code, expire.Milliseconds()}); err != nil {
+			return
+		}
+		if n, ok := rst.(int64); ok {
+			token = n
+			rst = evalOk
+		}
+		return
+	})
+	return
+}
+
+// CheckToken records the latest token writing resource in the db,
+// and returns ErrStaleToken when token is older than it.
+func (l *DistributedLock) CheckToken(c context.Context, resource string, token int64) (err error) {
+	return replyErr(l.db.Eval(c, fenceLuaScript, []string{"fence:" + resource}, []any{token}))
+}
@@ -0,0 +1,127 @@
+package lock
+
+/*
+ * @abstract leader election by DistributedLock
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Leader is a candidate of key, only one candidate of all servers is the leader at a time.
+// The leader holds the lock with a watchdog, and the others campaign every interval.
+type Leader struct {
+	l        *DistributedLock
+	key      string
+	ttl      time.Duration
+	interval time.Duration
+
+	onElected func()
+	onRevoked func()
+
+	leader atomic.Bool
+}
+
+// NewLeader returns the instance for Leader, ttl is the lease of the lock.
+func NewLeader(l *DistributedLock, key string, ttl time.Duration) *Leader {
+	return &Leader{
+		l:         l,
+		key:       key,
+		ttl:       ttl,
+		interval:  ttl,
+		onElected: func() {},
+		onRevoked: func() {},
+	}
+}
+
+// Interval sets the max waiting of one campaign.
+func (e *Leader) Interval(d time.Duration) *Leader {
+	e.interval = d
+	return e
+}
+
+// OnElected sets the callback after being the leader.
+func (e *Leader) OnElected(fn func()) *Leader {
+	e.onElected = fn
+	return e
+}
+
+// OnRevoked sets the callback after losing the leadership.
+func (e *Leader) OnRevoked(fn func()) *Leader {
+	e.onRevoked = fn
+	return e
+}
+
+// IsLeader returns whether it is the leader now.
+func (e *Leader) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Run campaigns until c is done, and runs fn every time it is elected.
+// The context of fn is canceled when the leadership is lost.
+// When c is done, it releases the lock at once, so another candidate takes over quickly,
+// especially with DistributedLock.Notify.
+// It returns when c is done or fn returns, with the error of fn.
+func (e *Leader) Run(c context.Context, fn func(c context.Context) error) (err error) {
+	for {
+		begin := time.Now()
+		var w *Watchdog
+		if w, err = e.l.LockWithWatchdog(c, e.key, e.ttl, e.interval); err != nil {
+			// wait for the rest of interval, as the db may fail quickly.
+			t := time.NewTimer(e.interval - time.Since(begin))
+			select {
+			case <-c.Done():
+				t.Stop()
+				return nil
+			case <-t.C:
+			}
+			continue
+		}
+
+		var done bool
+		done, err = e.lead(c, w, fn)
+		if done {
+			return
+		}
+	}
+}
+
+// lead runs fn as the leader until fn returns, the leadership is lost or c is done.
+func (e *Leader) lead(c context.Context, w *Watchdog, fn func(c context.Context) error) (done bool, err error) {
+	ctx, cancel := context.WithCancel(c)
+	defer cancel()
+
+	e.leader.Store(true)
+	e.onElected()
+
+	rst := make(chan error, 1)
+	go func() {
+		rst <- fn(ctx)
+	}()
+
+	select {
+	case err = <-rst:
+		e.leader.Store(false)
+		done = true
+	case <-c.Done():
+		e.leader.Store(false)
+		err = <-rst
+		done = true
+	case <-w.Lost():
+		e.leader.Store(false)
+		cancel()
+		<-rst
+	}
+
+	// c may be done already.
+	uc, ucancel := context.WithTimeout(context.WithoutCancel(c), e.ttl)
+	defer ucancel()
+	w.Unlock(uc)
+
+	e.onRevoked()
+	return
+}
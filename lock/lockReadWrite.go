@@ -0,0 +1,147 @@
+package lock
+
+/*
+ * @abstract read-write lock for multi-server in one redis instance
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"time"
+)
+
+// The writer holds KEYS[1] as DistributedLock does,
+// the readers are the members of KEYS[2] scored by their expire time,
+// and the waiting writer marks KEYS[3] to keep the new readers out.
+
+// args:3 keyName keyName:read keyName:write code 10000 nowMs 2000
+var readLockLuaScript = `
+local wkey=KEYS[1]
+local rkey=KEYS[2]
+local qkey=KEYS[3]
+local code=ARGV[1]
+local expire=tonumber(ARGV[2])
+local now=tonumber(ARGV[3])
+if(redis.call('EXISTS', wkey)==1 or redis.call('EXISTS', qkey)==1) then
+	return 'set fail'
+end
+redis.call('ZREMRANGEBYSCORE', rkey, '-inf', now)
+redis.call('ZADD', rkey, now+expire, code)
+if(redis.call('PTTL', rkey)<expire) then
+	redis.call('PEXPIRE', rkey, expire)
+end
+return '` + evalOk + `'
+`
+
+// args:3 keyName keyName:read keyName:write code
+var readUnlockLuaScript = `
+local rkey=KEYS[2]
+local code=ARGV[1]
+if(redis.call('ZREM', rkey, code)==0) then
+	return 'get fail'
+end
+return '` + evalOk + `'
+`
+
+// args:3 keyName keyName:read keyName:write code 10000 nowMs 2000
+var writeLockLuaScript = `
+local wkey=KEYS[1]
+local rkey=KEYS[2]
+local qkey=KEYS[3]
+local code=ARGV[1]
+local expire=ARGV[2]
+local now=ARGV[3]
+local waiting=ARGV[4]
+if(redis.call('EXISTS', wkey)==1) then
+	redis.call('SET', qkey, code, 'PX', waiting)
+	return 'set fail'
+end
+redis.call('ZREMRANGEBYSCORE', rkey, '-inf', now)
+if(redis.call('ZCARD', rkey)>0) then
+	redis.call('SET', qkey, code, 'PX', waiting)
+	return 'set fail'
+end
+redis.call('SET', wkey, code, 'PX', expire)
+redis.call('DEL', qkey)
+return '` + evalOk + `'
+`
+
+// args:3 keyName keyName:read keyName:write code
+var writeGiveUpLuaScript = `
+local qkey=KEYS[3]
+local code=ARGV[1]
+if(redis.call('GET', qkey)==code) then
+	redis.call('DEL', qkey)
+end
+return '` + evalOk + `'
+`
+
+// ReadWriteLock is the lock held by many readers or one writer.
+// The writers are preferred, the new readers wait while a writer is waiting,
+// so the writer does not starve under the steady reading.
+// A writer crashed while waiting keeps the new readers out for twice MaxDuration of DistributedLock at most.
+type ReadWriteLock struct {
+	l *DistributedLock
+}
+
+// NewReadWriteLock returns the instance for ReadWriteLock.
+func NewReadWriteLock(d IDistributedLockDb) *ReadWriteLock {
+	return &ReadWriteLock{l: NewDistributedLock(d)}
+}
+
+// GenUniqCodeFn returns a unique code.
+func (rw *ReadWriteLock) GenUniqCodeFn(fn func() (s string, err error)) *ReadWriteLock {
+	rw.l.GenUniqCodeFn(fn)
+	return rw
+}
+
+// Duration sets the duration on lock.
+func (rw *ReadWriteLock) Duration(d time.Duration) *ReadWriteLock {
+	rw.l.Duration(d)
+	return rw
+}
+
+// RLock locks for reading, it waits while a writer holds or waits for the lock.
+func (rw *ReadWriteLock) RLock(c context.Context, key string, expire, wait time.Duration) (code string, err error) {
+	return rw.lock(c, readLockLuaScript, key, expire, wait)
+}
+
+// RUnLock unlocks for reading.
+func (rw *ReadWriteLock) RUnLock(c context.Context, key string, code string) (err error) {
+	return replyErr(rw.l.db.Eval(c, readUnlockLuaScript, getReadWriteLockKeys(key), []any{code}))
+}
+
+// Lock locks for writing, it waits while any reader or writer holds the lock,
+// and keeps the new readers out while waiting.
+func (rw *ReadWriteLock) Lock(c context.Context, key string, expire, wait time.Duration) (code string, err error) {
+	if code, err = rw.lock(c, writeLockLuaScript, key, expire, wait); err != nil && code != "" {
+		// let the readers in at once.
+		rw.l.db.Eval(context.WithoutCancel(c), writeGiveUpLuaScript, getReadWriteLockKeys(key), []any{code})
+		code = ""
+	}
+	return
+}
+
+// UnLock unlocks for writing.
+func (rw *ReadWriteLock) UnLock(c context.Context, key string, code string) (err error) {
+	return replyErr(rw.l.db.Eval(c, unlockLuaScript, getReadWriteLockKeys(key)[:1], []any{code}))
+}
+
+func (rw *ReadWriteLock) lock(c context.Context, script string, key string, expire, wait time.Duration) (code string, err error) {
+	if code, err = rw.l.genCode(); err != nil {
+		return
+	}
+	keys := getReadWriteLockKeys(key)
+
+	waiting := 2 * max(rw.l.maxDuration, rw.l.duration)
+	err = rw.l.acquire(c, keys[0], wait, func() (any, error) {
+		return rw.l.db.Eval(c, script, keys, []any{code, expire.Milliseconds(), time.Now().UnixMilli(), waiting.Milliseconds()})
+	})
+	return
+}
+
+func getReadWriteLockKeys(key string) []string {
+	key = getLockKey(key)
+	return []string{key, key + ":read", key + ":write"}
+}
@@ -0,0 +1,159 @@
+package lock
+
+/*
+ * @abstract lock for multi-server in the majority of independent redis instances
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Redlock is the lock acquired on the majority of independent instances,
+// so that a failover of one instance does not make two holders.
+type Redlock struct {
+	dbs         []IDistributedLockDb
+	duration    time.Duration
+	driftFactor float64
+	genCode     func() (s string, err error)
+}
+
+// NewRedlock returns the instance for Redlock.
+func NewRedlock(ds ...IDistributedLockDb) *Redlock {
+	return &Redlock{
+		dbs:         ds,
+		duration:    time.Duration(50) * time.Millisecond,
+		driftFactor: 0.01,
+		genCode: func() (s string, err error) {
+			var id uuid.UUID
+			if id, err = uuid.NewRandom(); err == nil {
+				s = id.String()
+			}
+			return
+		},
+	}
+}
+
+// GenUniqCodeFn returns a unique code.
+func (l *Redlock) GenUniqCodeFn(fn func() (s string, err error)) *Redlock {
+	l.genCode = fn
+	return l
+}
+
+// Duration sets the max duration between retries, the real one is random to avoid the split vote.
+func (l *Redlock) Duration(d time.Duration) *Redlock {
+	l.duration = d
+	return l
+}
+
+// DriftFactor sets the factor of clock drift to expire.
+func (l *Redlock) DriftFactor(f float64) *Redlock {
+	l.driftFactor = f
+	return l
+}
+
+func (l *Redlock) quorum() int {
+	return len(l.dbs)/2 + 1
+}
+
+// Lock locks on the majority of instances within wait,
+// validity is the time the lock is safe to be held for.
+func (l *Redlock) Lock(c context.Context, key string, expire, wait time.Duration) (code string, validity time.Duration, err error) {
+	if len(l.dbs) == 0 {
+		err = ErrNoInstance
+		return
+	}
+	if code, err = l.genCode(); err != nil {
+		return
+	}
+	lockKey := getLockKey(key)
+	drift := time.Duration(float64(expire)*l.driftFactor) + 2*time.Millisecond
+
+	endTs := time.Now().Add(wait)
+	for {
+		begin := time.Now()
+		var n int
+		n, err = l.each(c, expire, func(c context.Context, db IDistributedLockDb) (err error) {
+			return replyErr(db.Eval(c, lockLuaScript, []string{lockKey}, []any{code, expire.Milliseconds()}))
+		})
+
+		validity = expire - time.Since(begin) - drift
+		if n >= l.quorum() && validity > 0 {
+			err = nil
+			return
+		}
+		if err == nil {
+			err = errors.New("validity expired")
+		}
+		err = fmt.Errorf("%w: %w", ErrNotAcquired, err)
+
+		// release the minority
+		l.UnLock(c, key, code)
+		validity = 0
+
+		if !time.Now().Before(endTs) {
+			err = fmt.Errorf("%w: %w", ErrTimeout, err)
+			return
+		}
+		select {
+		case <-c.Done():
+			err = c.Err()
+			return
+		case <-time.After(time.Duration(rand.Int63n(int64(l.duration) + 1))):
+		}
+	}
+}
+
+// UnLock unlocks on all instances, it is successful when the majority were released.
+func (l *Redlock) UnLock(c context.Context, key string, code string) (err error) {
+	lockKey := getLockKey(key)
+	var n int
+	n, err = l.each(c, 0, func(c context.Context, db IDistributedLockDb) (err error) {
+		return replyErr(db.Eval(c, unlockLuaScript, []string{lockKey}, []any{code}))
+	})
+	if n >= l.quorum() {
+		err = nil
+	}
+	return
+}
+
+// each runs fn on all instances concurrently and returns the count of success and the joined errors.
+// The time limit of every instance is a tenth of expire, to skip the unavailable one quickly.
+func (l *Redlock) each(c context.Context, expire time.Duration, fn func(c context.Context, db IDistributedLockDb) error) (n int, err error) {
+	var lock sync.Mutex
+	var errs []error
+	var wg sync.WaitGroup
+	wg.Add(len(l.dbs))
+	for _, db := range l.dbs {
+		go func(db IDistributedLockDb) {
+			defer wg.Done()
+
+			ctx := c
+			if expire > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(c, expire/10)
+				defer cancel()
+			}
+
+			e := fn(ctx, db)
+			lock.Lock()
+			defer lock.Unlock()
+			if e != nil {
+				errs = append(errs, e)
+				return
+			}
+			n++
+		}(db)
+	}
+	wg.Wait()
+	err = errors.Join(errs...)
+	return
+}
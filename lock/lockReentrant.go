@@ -0,0 +1,85 @@
+package lock
+
+/*
+ * @abstract reentrant lock for multi-server in one redis instance
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"time"
+)
+
+// args:1 keyName code 10000
+var reentrantLockLuaScript = `
+local key=KEYS[1]
+local code=ARGV[1]
+local expire=ARGV[2]
+if(redis.call('EXISTS', key)==1 and redis.call('HEXISTS', key, code)==0) then
+	return 'set fail'
+end
+redis.call('HINCRBY', key, code, 1)
+redis.call('PEXPIRE', key, expire)
+return '` + evalOk + `'
+`
+
+// args:1 keyName code
+var reentrantUnlockLuaScript = `
+local key=KEYS[1]
+local code=ARGV[1]
+if(redis.call('EXISTS', key)==0) then
+	return 'get fail'
+end
+if(redis.call('HEXISTS', key, code)==0) then
+	return 'equal fail'
+end
+if(redis.call('HINCRBY', key, code, -1)>0) then
+	return '` + evalOk + `'
+end
+redis.call('DEL', key)
+return '` + evalOk + `'
+`
+
+// ReentrantLock is the lock which can be locked again by its owner,
+// and is released after unlocking as many times as locking.
+type ReentrantLock struct {
+	l *DistributedLock
+}
+
+// NewReentrantLock returns the instance for ReentrantLock.
+func NewReentrantLock(d IDistributedLockDb) *ReentrantLock {
+	return &ReentrantLock{l: NewDistributedLock(d)}
+}
+
+// GenUniqCodeFn returns a unique code.
+func (r *ReentrantLock) GenUniqCodeFn(fn func() (s string, err error)) *ReentrantLock {
+	r.l.GenUniqCodeFn(fn)
+	return r
+}
+
+// Duration sets the duration on lock.
+func (r *ReentrantLock) Duration(d time.Duration) *ReentrantLock {
+	r.l.Duration(d)
+	return r
+}
+
+// Lock locks by the owner code and resets the expire, an empty code means a new owner.
+func (r *ReentrantLock) Lock(c context.Context, key string, code string, expire, wait time.Duration) (owner string, err error) {
+	if owner = code; owner == "" {
+		if owner, err = r.l.genCode(); err != nil {
+			return
+		}
+	}
+	key = getLockKey(key)
+
+	err = r.l.acquire(c, key, wait, func() (any, error) {
+		return r.l.db.Eval(c, reentrantLockLuaScript, []string{key}, []any{owner, expire.Milliseconds()})
+	})
+	return
+}
+
+// UnLock decreases the hold count of the owner and unlocks when it is zero.
+func (r *ReentrantLock) UnLock(c context.Context, key string, code string) (err error) {
+	return replyErr(r.l.db.Eval(c, reentrantUnlockLuaScript, []string{getLockKey(key)}, []any{code}))
+}
@@ -0,0 +1,88 @@
+package lock
+
+/*
+ * @abstract counting semaphore for multi-server in one redis instance
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"time"
+)
+
+// The holders are the members of the key scored by their expire time,
+// so a crashed holder returns its permit after expire.
+
+// args:1 keyName code 10000 nowMs permits
+var semaphoreLockLuaScript = `
+local key=KEYS[1]
+local code=ARGV[1]
+local expire=tonumber(ARGV[2])
+local now=tonumber(ARGV[3])
+local permits=tonumber(ARGV[4])
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now)
+if(redis.call('ZCARD', key)>=permits) then
+	return 'set fail'
+end
+redis.call('ZADD', key, now+expire, code)
+if(redis.call('PTTL', key)<expire) then
+	redis.call('PEXPIRE', key, expire)
+end
+return '` + evalOk + `'
+`
+
+// args:1 keyName code
+var semaphoreUnlockLuaScript = `
+local key=KEYS[1]
+local code=ARGV[1]
+if(redis.call('ZREM', key, code)==0) then
+	return 'get fail'
+end
+return '` + evalOk + `'
+`
+
+// Semaphore is the lock held by at most permits holders.
+type Semaphore struct {
+	l       *DistributedLock
+	permits int64
+}
+
+// NewSemaphore returns the instance for Semaphore.
+func NewSemaphore(d IDistributedLockDb, permits int64) *Semaphore {
+	return &Semaphore{l: NewDistributedLock(d), permits: permits}
+}
+
+// GenUniqCodeFn returns a unique code.
+func (s *Semaphore) GenUniqCodeFn(fn func() (s string, err error)) *Semaphore {
+	s.l.GenUniqCodeFn(fn)
+	return s
+}
+
+// Duration sets the duration on lock.
+func (s *Semaphore) Duration(d time.Duration) *Semaphore {
+	s.l.Duration(d)
+	return s
+}
+
+// Lock takes a permit, it waits while all permits are held.
+func (s *Semaphore) Lock(c context.Context, key string, expire, wait time.Duration) (code string, err error) {
+	if s.permits <= 0 {
+		err = ErrNoPermit
+		return
+	}
+	if code, err = s.l.genCode(); err != nil {
+		return
+	}
+	key = getLockKey(key)
+
+	err = s.l.acquire(c, key, wait, func() (any, error) {
+		return s.l.db.Eval(c, semaphoreLockLuaScript, []string{key}, []any{code, expire.Milliseconds(), time.Now().UnixMilli(), s.permits})
+	})
+	return
+}
+
+// UnLock returns the permit.
+func (s *Semaphore) UnLock(c context.Context, key string, code string) (err error) {
+	return replyErr(s.l.db.Eval(c, semaphoreUnlockLuaScript, []string{getLockKey(key)}, []any{code}))
+}
@@ -0,0 +1,148 @@
+package lock
+
+/*
+ * @abstract watchdog renewing the lease of DistributedLock
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// args:1 keyName code 10000
+var extendLuaScript = `
+local key=KEYS[1]
+local code=ARGV[1]
+local expire=ARGV[2]
+local value=redis.call('GET', key)
+if(value==false) then
+	return 'get fail'
+end
+if(code~=value) then
+	return 'equal fail'
+end
+redis.call('PEXPIRE', key, expire)
+return '` + evalOk + `'
+`
+
+// Extend resets the expire of the lock held by code.
+func (l *DistributedLock) Extend(c context.Context, key string, code string, expire time.Duration) (err error) {
+	return replyErr(l.db.Eval(c, extendLuaScript, []string{getLockKey(key)}, []any{code, expire.Milliseconds()}))
+}
+
+// Watchdog is a held lock whose lease is renewed in the background
+// until Unlock or the context of locking is done.
+type Watchdog struct {
+	l      *DistributedLock
+	key    string
+	code   string
+	expire time.Duration
+
+	lost chan struct{}
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+
+	lock sync.Mutex
+	err  error
+}
+
+// LockWithWatchdog locks and renews the lease every third of expire in the background,
+// so a short expire recovers quickly when the holder crashes.
+func (l *DistributedLock) LockWithWatchdog(c context.Context, key string, expire, wait time.Duration) (w *Watchdog, err error) {
+	var code string
+	if code, err = l.Lock(c, key, expire, wait); err != nil {
+		return
+	}
+	w = l.watch(c, key, code, expire)
+	return
+}
+
+// watch renews the held lock until Unlock or c is done.
+func (l *DistributedLock) watch(c context.Context, key string, code string, expire time.Duration) (w *Watchdog) {
+	w = &Watchdog{
+		l:      l,
+		key:    key,
+		code:   code,
+		expire: expire,
+		lost:   make(chan struct{}),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go w.renew(c)
+	return
+}
+
+// Code returns the code of the held lock.
+func (w *Watchdog) Code() string {
+	return w.code
+}
+
+// Lost is closed when the lock was lost, such as expired or taken by others.
+func (w *Watchdog) Lost() <-chan struct{} {
+	return w.lost
+}
+
+// Err returns the reason of losing the lock.
+func (w *Watchdog) Err() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.err
+}
+
+// Unlock stops renewing and unlocks.
+func (w *Watchdog) Unlock(c context.Context) (err error) {
+	w.once.Do(func() {
+		close(w.stop)
+	})
+	<-w.done
+	return w.l.UnLock(c, w.key, w.code)
+}
+
+func (w *Watchdog) renew(c context.Context) {
+	defer close(w.done)
+
+	interval := w.expire / 3
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	renewed := time.Now()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-c.Done():
+			return
+		case <-t.C:
+		}
+
+		rst, err := w.l.db.Eval(c, extendLuaScript, []string{getLockKey(w.key)}, []any{w.code, w.expire.Milliseconds()})
+		if err == nil {
+			if err = replyErr(rst, nil); err != nil {
+				w.setLost(err)
+				return
+			}
+			renewed = time.Now()
+			continue
+		}
+
+		// the lease has expired while the db is unavailable.
+		if time.Since(renewed) >= w.expire {
+			w.setLost(err)
+			return
+		}
+	}
+}
+
+func (w *Watchdog) setLost(err error) {
+	w.lock.Lock()
+	w.err = err
+	w.lock.Unlock()
+	close(w.lost)
+}
@@ -0,0 +1,65 @@
+package lock
+
+/*
+ * @abstract running a function under DistributedLock
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// LockOptions is the options of WithLock.
+type LockOptions struct {
+	Expire time.Duration
+	Wait   time.Duration // 0 means TryLock.
+
+	// Watchdog renews the lease while fn is running,
+	// and the context of fn is canceled when the lock is lost.
+	Watchdog bool
+}
+
+// WithLock runs fn under the lock of key and always unlocks after fn returns,
+// it returns the error of locking, or the joined errors of fn and unlocking.
+func (l *DistributedLock) WithLock(c context.Context, key string, opts LockOptions, fn func(c context.Context) error) (err error) {
+	var code string
+	if opts.Wait > 0 {
+		code, err = l.Lock(c, key, opts.Expire, opts.Wait)
+	} else {
+		code, err = l.TryLock(c, key, opts.Expire)
+	}
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(c)
+	defer cancel()
+
+	unlock := func(c context.Context) error {
+		return l.UnLock(c, key, code)
+	}
+	if opts.Watchdog {
+		w := l.watch(ctx, key, code, opts.Expire)
+		unlock = w.Unlock
+		go func() {
+			select {
+			case <-w.Lost():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	defer func() {
+		// unlock even if c is done.
+		uc, ucancel := context.WithTimeout(context.WithoutCancel(c), opts.Expire)
+		defer ucancel()
+		err = errors.Join(err, unlock(uc))
+	}()
+
+	err = fn(ctx)
+	return
+}
@@ -0,0 +1,214 @@
+package ratelimit
+
+/*
+ * @abstract rate limiting middleware for handlers and queue consumers
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/neo532/gokit/limiter"
+	"github.com/neo532/gokit/metadata"
+	"github.com/neo532/gokit/middleware"
+	"github.com/neo532/gokit/queue"
+)
+
+// KeyGlobal is the key shared by the requests without their own key.
+const KeyGlobal = "global"
+
+// ErrLimited is the cause of LimitedError, matching it by errors.Is.
+var ErrLimited = errors.New("ratelimit: limited")
+
+// LimitedError is returned when the request is rejected.
+type LimitedError struct {
+	Key        string
+	RetryAfter time.Duration
+}
+
+func (e *LimitedError) Error() string {
+	return fmt.Sprintf("%s[key:%s][retry after:%v]", ErrLimited.Error(), e.Key, e.RetryAfter)
+}
+
+// Is reports whether target is ErrLimited.
+func (e *LimitedError) Is(target error) bool {
+	return target == ErrLimited
+}
+
+// Allower decides whether the key is allowed now and returns the time to wait for when it is not.
+type Allower func(c context.Context, key string) (bRst bool, retryAfter time.Duration, err error)
+
+// Freq returns an Allower counting by Freq.IncrCheck,
+// the retryAfter is the latest reset of the exhausted rules.
+func Freq(f *limiter.Freq, rule ...limiter.FreqRule) Allower {
+	return func(c context.Context, key string) (bRst bool, retryAfter time.Duration, err error) {
+		if bRst, err = f.IncrCheck(c, key, rule...); bRst || err != nil {
+			return
+		}
+
+		var qs []limiter.Quota
+		if qs, err = f.Quota(c, key, rule...); err != nil {
+			return
+		}
+		for _, q := range qs {
+			if q.Remaining == 0 && q.Reset > retryAfter {
+				retryAfter = q.Reset
+			}
+		}
+		return
+	}
+}
+
+// Rate returns an Allower shaping by Rate.Allow.
+func Rate(r *limiter.Rate, limit limiter.RateLimit) Allower {
+	return func(c context.Context, key string) (bRst bool, retryAfter time.Duration, err error) {
+		return r.Allow(c, key, limit)
+	}
+}
+
+// DefaultPrefix is the namespace of the keys without WithPrefix.
+const DefaultPrefix = "ratelimit"
+
+// KeyFunc returns the key of the request or the message, the empty key means KeyGlobal.
+type KeyFunc func(c context.Context, request any) string
+
+// ========== Option ==========
+type Option func(*options)
+
+type options struct {
+	prefix   string
+	key      KeyFunc
+	wait     bool
+	maxWait  time.Duration
+	interval time.Duration
+}
+
+// WithPrefix sets the namespace prepended to the key, as "<prefix>:<key>".
+// The middlewares with their own rules on the same db should have different prefixes,
+// otherwise they share the counters, the default is DefaultPrefix.
+func WithPrefix(s string) Option {
+	return func(o *options) {
+		o.prefix = s
+	}
+}
+
+// WithKey sets the function returning the key.
+func WithKey(fn KeyFunc) Option {
+	return func(o *options) {
+		o.key = fn
+	}
+}
+
+// WithHeaderKey uses the value of name in the server's metadata or the queue's header as the key.
+func WithHeaderKey(name string) Option {
+	return func(o *options) {
+		o.key = func(c context.Context, request any) string {
+			if md, ok := metadata.FromServerContext(c); ok {
+				if v := md.Get(name); v != "" {
+					return name + ":" + v
+				}
+			}
+			if h, ok := queue.GetHeaderFromContext(c); ok {
+				if v := h.Value(name); v != "" {
+					return name + ":" + v
+				}
+			}
+			return ""
+		}
+	}
+}
+
+// WithWait waits until allowed instead of rejecting, at most max and 0 means until the context is done.
+func WithWait(max time.Duration) Option {
+	return func(o *options) {
+		o.wait = true
+		o.maxWait = max
+	}
+}
+
+// WithInterval sets the waiting interval when the Allower gives no retryAfter.
+func WithInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.interval = d
+	}
+}
+
+// ========== /Option ==========
+
+func newOptions(opts ...Option) (o *options) {
+	o = &options{
+		prefix:   DefaultPrefix,
+		key:      func(c context.Context, request any) string { return "" },
+		interval: 50 * time.Millisecond,
+	}
+	for _, fn := range opts {
+		fn(o)
+	}
+	return
+}
+
+// Server returns a middleware.Middleware limiting the requests.
+func Server(allow Allower, opts ...Option) middleware.Middleware {
+	o := newOptions(opts...)
+	return func(next middleware.Handler) middleware.Handler {
+		return func(c context.Context, request, reply any) (context.Context, error) {
+			if err := o.take(c, allow, request); err != nil {
+				return c, err
+			}
+			return next(c, request, reply)
+		}
+	}
+}
+
+// Consumer returns a queue.ConsumerMiddleware throttling the messages.
+func Consumer(allow Allower, opts ...Option) queue.ConsumerMiddleware {
+	o := newOptions(opts...)
+	return func(next queue.ConsumerHandler) queue.ConsumerHandler {
+		return func(c context.Context, message []byte) error {
+			if err := o.take(c, allow, message); err != nil {
+				return err
+			}
+			return next(c, message)
+		}
+	}
+}
+
+// take returns nil when allowed, or waits for it in the wait mode.
+func (o *options) take(c context.Context, allow Allower, request any) (err error) {
+	key := o.key(c, request)
+	if key == "" {
+		key = KeyGlobal
+	}
+	key = o.prefix + ":" + key
+
+	begin := time.Now()
+	for {
+		var ok bool
+		var retryAfter time.Duration
+		if ok, retryAfter, err = allow(c, key); err != nil || ok {
+			return
+		}
+		if !o.wait {
+			return &LimitedError{Key: key, RetryAfter: retryAfter}
+		}
+
+		if retryAfter <= 0 {
+			retryAfter = o.interval
+		}
+		if o.maxWait > 0 && time.Since(begin)+retryAfter > o.maxWait {
+			return &LimitedError{Key: key, RetryAfter: retryAfter}
+		}
+
+		t := time.NewTimer(retryAfter)
+		select {
+		case <-c.Done():
+			t.Stop()
+			return c.Err()
+		case <-t.C:
+		}
+	}
+}
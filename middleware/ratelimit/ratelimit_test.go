@@ -0,0 +1,143 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neo532/gokit/metadata"
+	"github.com/neo532/gokit/queue"
+)
+
+// counter allows times requests of every key, then allows one more after retryAfter.
+func counter(times int, retryAfter time.Duration) Allower {
+	var lock sync.Mutex
+	used := make(map[string]int)
+	last := make(map[string]time.Time)
+	return func(c context.Context, key string) (bool, time.Duration, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		if used[key] < times || (!last[key].IsZero() && time.Since(last[key]) >= retryAfter) {
+			used[key]++
+			last[key] = time.Now()
+			return true, 0, nil
+		}
+		return false, retryAfter - time.Since(last[key]), nil
+	}
+}
+
+func TestServer(t *testing.T) {
+	handler := func(c context.Context, request, reply any) (context.Context, error) {
+		return c, nil
+	}
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		ctx     func() context.Context
+		timeout time.Duration
+		times   int
+		err     error
+	}{
+		{
+			name:  "reject",
+			ctx:   context.Background,
+			times: 2,
+			err:   ErrLimited,
+		},
+		{
+			name: "reject by header key",
+			opts: []Option{WithHeaderKey("x-user")},
+			ctx: func() context.Context {
+				return metadata.NewServerContext(context.Background(), metadata.New(map[string][]string{"x-user": {"1"}}))
+			},
+			times: 2,
+			err:   ErrLimited,
+		},
+		{
+			name:  "wait",
+			opts:  []Option{WithWait(time.Second)},
+			ctx:   context.Background,
+			times: 2,
+		},
+		{
+			name:  "wait too long",
+			opts:  []Option{WithWait(10 * time.Millisecond)},
+			ctx:   context.Background,
+			times: 2,
+			err:   ErrLimited,
+		},
+		{
+			name:    "wait until canceled",
+			opts:    []Option{WithWait(0)},
+			ctx:     context.Background,
+			timeout: 10 * time.Millisecond,
+			times:   2,
+			err:     context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Server(counter(tt.times, 50*time.Millisecond), tt.opts...)(handler)
+			c := tt.ctx()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				c, cancel = context.WithTimeout(c, tt.timeout)
+				defer cancel()
+			}
+
+			for i := 0; i < tt.times; i++ {
+				if _, err := h(c, nil, nil); err != nil {
+					t.Errorf("%s has err[%+v]", t.Name(), err)
+				}
+			}
+			_, err := h(c, nil, nil)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, tt.err)
+			}
+
+			var le *LimitedError
+			if errors.As(err, &le) && le.RetryAfter <= 0 {
+				t.Errorf("%s has wrong retryAfter %v", t.Name(), le.RetryAfter)
+			}
+		})
+	}
+}
+
+func TestConsumer(t *testing.T) {
+	var keys []string
+	allow := func(c context.Context, key string) (bool, time.Duration, error) {
+		keys = append(keys, key)
+		return len(keys) == 1, time.Minute, nil
+	}
+	handler := queue.ChainConsumer(Consumer(allow, WithHeaderKey("topic")))(
+		func(c context.Context, message []byte) error {
+			return nil
+		},
+	)
+
+	c := queue.AppendHeaderToContext(queue.InitHeaderToContext(context.Background()), "topic", "order")
+	if err := handler(c, []byte("1")); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+	}
+	if err := handler(context.Background(), []byte("2")); !errors.Is(err, ErrLimited) {
+		t.Errorf("%s has err[%+v] should [%+v]", t.Name(), err, ErrLimited)
+	}
+	if len(keys) != 2 || keys[0] != DefaultPrefix+":topic:order" || keys[1] != DefaultPrefix+":"+KeyGlobal {
+		t.Errorf("%s has wrong keys %+v", t.Name(), keys)
+	}
+
+	keys = keys[:0]
+	handler = queue.ChainConsumer(Consumer(allow, WithPrefix("order")))(
+		func(c context.Context, message []byte) error {
+			return nil
+		},
+	)
+	handler(context.Background(), []byte("3"))
+	if len(keys) != 1 || keys[0] != "order:"+KeyGlobal {
+		t.Errorf("%s has wrong keys %+v", t.Name(), keys)
+	}
+}
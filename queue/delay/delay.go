@@ -0,0 +1,319 @@
+package delay
+
+/*
+ * @abstract delayed producer parking the messages in a sorted set until due
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/neo532/gokit/logger"
+	"github.com/neo532/gokit/queue"
+)
+
+const evalOk = "ok"
+
+// ErrNotFound is returned when cancelling the message which has been moved or cancelled.
+var ErrNotFound = errors.New("delay: not found")
+
+// args:2 zsetKey hashKey id value at
+var parkLuaScript = `
+local zkey=KEYS[1]
+local hkey=KEYS[2]
+local id=ARGV[1]
+local value=ARGV[2]
+local at=ARGV[3]
+redis.call('HSET', hkey, id, value)
+redis.call('ZADD', zkey, at, id)
+return '` + evalOk + `'
+`
+
+// args:2 zsetKey hashKey now limit lease
+var claimLuaScript = `
+local zkey=KEYS[1]
+local hkey=KEYS[2]
+local now=tonumber(ARGV[1])
+local limit=ARGV[2]
+local lease=tonumber(ARGV[3])
+local ids=redis.call('ZRANGEBYSCORE', zkey, '-inf', now, 'LIMIT', 0, limit)
+local rst={}
+for _, id in ipairs(ids) do
+	local value=redis.call('HGET', hkey, id)
+	if(value==false) then
+		redis.call('ZREM', zkey, id)
+	else
+		redis.call('ZADD', zkey, now+lease, id)
+		table.insert(rst, id)
+		table.insert(rst, value)
+	end
+end
+return rst
+`
+
+// args:2 zsetKey hashKey id
+var removeLuaScript = `
+local zkey=KEYS[1]
+local hkey=KEYS[2]
+local id=ARGV[1]
+redis.call('ZREM', zkey, id)
+local rst=redis.call('HDEL', hkey, id)
+if(rst==0) then
+	return 'not found'
+end
+return '` + evalOk + `'
+`
+
+// args:3 zsetKey hashKey deadKey id value
+var buryLuaScript = `
+local zkey=KEYS[1]
+local hkey=KEYS[2]
+local dkey=KEYS[3]
+local id=ARGV[1]
+local value=ARGV[2]
+redis.call('ZREM', zkey, id)
+redis.call('HDEL', hkey, id)
+redis.call('HSET', dkey, id, value)
+return '` + evalOk + `'
+`
+
+// IDelayDb is the interface for Producer's db, such as redis or database/memory.
+type IDelayDb interface {
+	Eval(c context.Context, cmd string, keys []string, args []any) (rst any, err error)
+}
+
+var _ queue.Producer = (*Producer)(nil)
+
+// envelope is the parked message, Value is encoded by the encoder of Producer.
+type envelope struct {
+	Value  []byte       `json:"value"`
+	Header queue.Header `json:"header,omitempty"`
+}
+
+// Producer parks the messages in a sorted set scored by the due time,
+// and Run moves them to the target producer when due, with the header parked together.
+// The undecodable parked ones are moved to the hash "delay:<name>:dead".
+type Producer struct {
+	Name string
+
+	db         IDelayDb
+	target     queue.Producer
+	zsetKey    string
+	hashKey    string
+	deadKey    string
+	interval   time.Duration
+	batch      int
+	lease      time.Duration
+	genID      func() (id string, err error)
+	encoder    func(message any) (msg []byte, err error)
+	logger     logger.ILogger
+	middleware []queue.ProducerMiddleware
+}
+
+// New returns a delayed producer of target, name is the key of the parked messages,
+// so the one per target topic is suggested.
+func New(name string, db IDelayDb, target queue.Producer, opts ...Option) (pdc *Producer) {
+	pdc = &Producer{
+		Name:     name,
+		db:       db,
+		target:   target,
+		zsetKey:  "delay:" + name,
+		hashKey:  "delay:" + name + ":msg",
+		deadKey:  "delay:" + name + ":dead",
+		interval: time.Second,
+		batch:    100,
+		lease:    30 * time.Second,
+		genID: func() (id string, err error) {
+			b := make([]byte, 16)
+			if _, err = rand.Read(b); err == nil {
+				id = hex.EncodeToString(b)
+			}
+			return
+		},
+		encoder:    json.Marshal,
+		logger:     logger.NewDefaultILogger(),
+		middleware: make([]queue.ProducerMiddleware, 0, 1),
+	}
+	for _, o := range opts {
+		o(pdc)
+	}
+	return
+}
+
+// Send sends the message to the target at once.
+func (pdc *Producer) Send(c context.Context, message any) (err error) {
+	return pdc.target.Send(c, message)
+}
+
+func (pdc *Producer) Close() func() {
+	return pdc.target.Close()
+}
+
+func (pdc *Producer) Error() error {
+	return pdc.target.Error()
+}
+
+// SendAfter parks the message and sends it to the target after d.
+func (pdc *Producer) SendAfter(c context.Context, d time.Duration, message any) (id string, err error) {
+	return pdc.SendAt(c, time.Now().Add(d), message)
+}
+
+// SendAt parks the message and sends it to the target at at, id is used to cancel it.
+func (pdc *Producer) SendAt(c context.Context, at time.Time, message any) (id string, err error) {
+	c = queue.InitHeaderToContext(c)
+
+	ps := []any{
+		queue.KeyName, pdc.Name,
+		"at", at,
+	}
+
+	if id, err = pdc.genID(); err != nil {
+		ps = append(ps, queue.KeyErr, err)
+		pdc.logger.Error(c, "Delayed producer's genID Has err!", ps...)
+		return
+	}
+	ps = append(ps, queue.KeyKey, id)
+
+	var msg []byte
+	if msg, err = queue.Encode(message, pdc.encoder); err != nil {
+		ps = append(ps, queue.KeyErr, err, queue.KeyMessage, message)
+		pdc.logger.Error(c, "Delayed producer's encoder Has err!", ps...)
+		return
+	}
+	ps = append(ps, queue.KeyMessage, string(msg))
+
+	h := func(c context.Context, message any) (err error) {
+		header, _ := queue.GetHeaderFromContext(c)
+		var b []byte
+		if b, err = json.Marshal(envelope{Value: msg, Header: header}); err != nil {
+			return
+		}
+		return replyErr(pdc.db.Eval(c, parkLuaScript,
+			[]string{pdc.zsetKey, pdc.hashKey},
+			[]any{id, string(b), at.UnixMilli()},
+		))
+	}
+
+	if len(pdc.middleware) > 0 {
+		h = queue.ChainProducer(pdc.middleware...)(h)
+	}
+
+	if err = h(c, message); err != nil {
+		ps = append(ps, queue.KeyErr, err)
+		pdc.logger.Error(c, "Delayed producer's parking Has err!", ps...)
+		return
+	}
+
+	pdc.logger.Info(c, "Delayed producer have been parked!", ps...)
+	return
+}
+
+// Cancel cancels the parked message, it returns ErrNotFound if it has been moved or cancelled.
+func (pdc *Producer) Cancel(c context.Context, id string) (err error) {
+	return replyErr(pdc.db.Eval(c, removeLuaScript, []string{pdc.zsetKey, pdc.hashKey}, []any{id}))
+}
+
+// Run moves the due messages to the target every interval until c is done.
+// It is safe to run on multiple instances, a message is claimed by one of them for lease.
+func (pdc *Producer) Run(c context.Context) (err error) {
+	t := time.NewTicker(pdc.interval)
+	defer t.Stop()
+	for {
+		// a full batch means more due messages.
+		n := pdc.Move(c)
+		if n >= pdc.batch && c.Err() == nil {
+			continue
+		}
+		select {
+		case <-c.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Move moves the due messages once and returns the count of the claimed ones.
+func (pdc *Producer) Move(c context.Context) (n int) {
+	rst, err := pdc.db.Eval(c, claimLuaScript,
+		[]string{pdc.zsetKey, pdc.hashKey},
+		[]any{time.Now().UnixMilli(), pdc.batch, pdc.lease.Milliseconds()},
+	)
+	if err != nil {
+		if c.Err() == nil {
+			pdc.logger.Error(c, "Delayed producer's claiming Has err!",
+				queue.KeyName, pdc.Name,
+				queue.KeyErr, err,
+			)
+		}
+		return
+	}
+
+	kvs, _ := rst.([]any)
+	n = len(kvs) / 2
+	for i := 0; i+1 < len(kvs); i += 2 {
+		id, _ := kvs[i].(string)
+		value, _ := kvs[i+1].(string)
+		pdc.move(c, id, value)
+	}
+	return
+}
+
+// move sends the claimed message to the target unchanged and removes it,
+// it is moved again after lease if failed.
+func (pdc *Producer) move(c context.Context, id string, value string) {
+	ps := []any{
+		queue.KeyName, pdc.Name,
+		queue.KeyKey, id,
+		queue.KeyMessage, value,
+	}
+
+	var e envelope
+	if err := json.Unmarshal([]byte(value), &e); err != nil {
+		ps = append(ps, queue.KeyErr, err)
+		pdc.logger.Error(c, "Delayed producer's decoder Has err!", ps...)
+
+		// it never succeeds, so it is buried instead of being claimed again.
+		if err = replyErr(pdc.db.Eval(c, buryLuaScript,
+			[]string{pdc.zsetKey, pdc.hashKey, pdc.deadKey},
+			[]any{id, value},
+		)); err != nil {
+			ps = append(ps, "buryErr", err)
+			pdc.logger.Error(c, "Delayed producer's burying Has err!", ps...)
+		}
+		return
+	}
+
+	if err := pdc.target.Send(queue.WithForwardHeader(c, e.Header), queue.Encoded(e.Value)); err != nil {
+		ps = append(ps, queue.KeyErr, err)
+		pdc.logger.Error(c, "Delayed producer's moving Has err!", ps...)
+		return
+	}
+
+	if err := pdc.Cancel(c, id); err != nil && !errors.Is(err, ErrNotFound) {
+		ps = append(ps, queue.KeyErr, err)
+		pdc.logger.Error(c, "Delayed producer's removing Has err!", ps...)
+		return
+	}
+	pdc.logger.Info(c, "Delayed producer have been moved!", ps...)
+}
+
+// replyErr returns the error of the reply of the scripts.
+func replyErr(rst any, err error) error {
+	if err != nil {
+		return err
+	}
+	switch e, _ := rst.(string); e {
+	case evalOk:
+		return nil
+	case "not found":
+		return ErrNotFound
+	}
+	return fmt.Errorf("delay: invalid reply %v", rst)
+}
@@ -0,0 +1,69 @@
+package delay
+
+/*
+ * @abstract delayed producer's option
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"time"
+
+	"github.com/neo532/gokit/logger"
+	"github.com/neo532/gokit/queue"
+)
+
+// ========== Option ==========
+type Option func(*Producer)
+
+func WithLogger(l logger.ILogger) Option {
+	return func(o *Producer) {
+		o.logger = l
+	}
+}
+
+// WithInterval sets the interval of polling the due messages.
+func WithInterval(t time.Duration) Option {
+	return func(o *Producer) {
+		o.interval = t
+	}
+}
+
+// WithBatch sets the max count of the due messages moved in one polling, the non-positive n is ignored.
+func WithBatch(n int) Option {
+	return func(o *Producer) {
+		if n > 0 {
+			o.batch = n
+		}
+	}
+}
+
+// WithLease sets the time a due message is hidden from the other movers while publishing,
+// it is moved again after lease if the publishing failed or the mover crashed.
+func WithLease(t time.Duration) Option {
+	return func(o *Producer) {
+		o.lease = t
+	}
+}
+
+// WithEncoder sets the encoder of the message on parking, json by default.
+// It should be the same as the one of target, as the encoded message is sent to target unchanged.
+func WithEncoder(fn func(message any) (msg []byte, err error)) Option {
+	return func(o *Producer) {
+		o.encoder = fn
+	}
+}
+
+// WithGenIDFn sets the generator of the message id.
+func WithGenIDFn(fn func() (id string, err error)) Option {
+	return func(o *Producer) {
+		o.genID = fn
+	}
+}
+
+// WithMiddleware sets the middlewares run on parking, the header set by them survives the move.
+func WithMiddleware(ms ...queue.ProducerMiddleware) Option {
+	return func(o *Producer) {
+		o.middleware = append(o.middleware, ms...)
+	}
+}
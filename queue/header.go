@@ -6,12 +6,36 @@ import (
 	"strings"
 )
 
+// The headers of the forwarded message by the failure policy of consumers, besides the original ones.
+const (
+	HeaderError           = "x-error"
+	HeaderAttempt         = "x-attempt"
+	HeaderRetry           = "x-retry"
+	HeaderRetryAt         = "x-retry-at"
+	HeaderSourceTopic     = "x-source-topic"
+	HeaderSourcePartition = "x-source-partition"
+	HeaderSourceOffset    = "x-source-offset"
+)
+
 type Header map[string]string
 type headerKey struct{}
+type forwardHeaderKey struct{}
 
 // New creates an Header from a given key-values map.
 func InitHeaderToContext(c context.Context) context.Context {
-	return context.WithValue(c, headerKey{}, Header{})
+	h := Header{}
+	if fh, ok := c.Value(forwardHeaderKey{}).(Header); ok {
+		for k, v := range fh {
+			h[k] = v
+		}
+	}
+	return context.WithValue(c, headerKey{}, h)
+}
+
+// WithForwardHeader returns a context whose header is initialized with h by InitHeaderToContext,
+// so the producer sends h along with the message, such as forwarding a consumed message.
+func WithForwardHeader(c context.Context, h Header) context.Context {
+	return context.WithValue(c, forwardHeaderKey{}, h)
 }
 
 // GetHeaderFromContext returns the header in ctx if it exists.
@@ -49,6 +49,10 @@ func NewGroup(name string, addrs []string, group string, opts ...Option) (csm *C
 			slowTime:   3 * time.Second,
 			logger:     logger.NewDefaultILogger(),
 			middleware: make([]queue.ConsumerMiddleware, 0, 1),
+			failure: failure{
+				backoff:    100 * time.Millisecond,
+				maxBackoff: 10 * time.Second,
+			},
 		},
 		bootstrapContext: context.Background(),
 	}
@@ -161,6 +165,7 @@ type groupHandler struct {
 	slowTime   time.Duration
 	logger     logger.ILogger
 	middleware []queue.ConsumerMiddleware
+	failure    failure
 }
 
 // Setup is run at the beginning of a new session, before ConsumeClaim
@@ -191,50 +196,55 @@ func (h *groupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim s
 		time.Sleep(time.Second)
 	}()
 
-	hdl := func(c context.Context, message []byte) (err error) {
+	var hdl queue.ConsumerHandler = func(c context.Context, message []byte) (err error) {
 		err = h.handler(c, message)
 		return
 	}
+	if len(h.middleware) > 0 {
+		hdl = queue.ChainConsumer(h.middleware...)(hdl)
+	}
 
 	for {
 		select {
 		case m, ok := <-claim.Messages():
 
+			if !ok {
+				h.logger.Warn(c, "message channel was closed!", queue.KeyName, h.name)
+				return
+			}
+
 			message = m.Value
 			ps = []any{
 				queue.KeyName, h.name,
+				queue.KeyTopic, m.Topic,
 				queue.KeyPartition, m.Partition,
 				queue.KeyOffset, m.Offset,
 				queue.KeyMessage, string(message),
 			}
 
-			if !ok {
-				h.logger.Warn(c, "message channel was closed!", ps...)
-				return
-			}
-
-			c = queue.InitHeaderToContext(c)
+			c := queue.InitHeaderToContext(session.Context())
 			if header, ok := queue.GetHeaderFromContext(c); ok {
 				for _, h := range m.Headers {
 					header.Set(string(h.Key), string(h.Value))
 				}
 			}
 
-			if len(h.middleware) > 0 {
-				hdl = queue.ChainConsumer(h.middleware...)(hdl)
-			}
-
 			begin := time.Now()
-			if err = hdl(c, message); err != nil {
+			var handled bool
+			if handled, err = h.process(c, m, hdl); !handled {
+				// unmarked, it will be consumed again by the next session.
 				ps = append(ps, queue.KeyErr, err)
-				h.logger.Error(c, "Consumer's Has err!", ps...)
-				continue
+				h.logger.Warn(c, "Consumer was canceled before handled!", ps...)
+				return nil
 			}
 			cost := time.Since(begin)
 			ps = append(ps, "cost", cost)
 
-			// mark ok
+			// mark ok, the failed one has been forwarded by the failure policy.
 			session.MarkMessage(m, "")
+			if !h.autoCommit {
+				session.Commit()
+			}
 
 			// biz error
 			if err != nil {
@@ -243,10 +253,6 @@ func (h *groupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim s
 				continue
 			}
 
-			if !h.autoCommit {
-				session.Commit()
-			}
-
 			// slow
 			if cost > h.slowTime {
 				ps = append(ps,
@@ -8,11 +8,16 @@ package consumergroup
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
 
+	"github.com/IBM/sarama"
+
 	"github.com/neo532/gokit/queue"
+	"github.com/neo532/gokit/queue/memory"
+	"github.com/neo532/gokit/queue/memory/producer"
 )
 
 func TraceID() queue.ConsumerMiddleware {
@@ -68,3 +73,83 @@ func TestConsumer(t *testing.T) {
 		}
 	}
 }
+
+func TestFailure(t *testing.T) {
+	b := memory.NewBroker()
+	retry := producer.New("retry", b, producer.WithTopic("message.retry"))
+	dead := producer.New("dead", b, producer.WithTopic("message.dlq"))
+
+	var count int
+	csm, _ := NewGroup(
+		"default",
+		[]string{"127.0.0.1:9092"},
+		"sender",
+		WithRetry(2, time.Millisecond, time.Millisecond),
+		WithRetryTopic(retry, 10*time.Millisecond),
+		WithDeadLetter(dead),
+		WithHandler(func(ctx context.Context, message []byte) (err error) {
+			count++
+			return errors.New("biz error")
+		}),
+	)
+	h := csm.handler
+	hdl := func(c context.Context, message []byte) error {
+		return h.handler(c, message)
+	}
+	process := func(m *sarama.ConsumerMessage) {
+		c := queue.InitHeaderToContext(context.Background())
+		header, _ := queue.GetHeaderFromContext(c)
+		for _, rh := range m.Headers {
+			header.Set(string(rh.Key), string(rh.Value))
+		}
+		if ok, err := h.process(c, m, hdl); !ok || err == nil {
+			t.Errorf("%s has err[%v %+v] should [true biz error]", t.Name(), ok, err)
+		}
+	}
+
+	process(&sarama.ConsumerMessage{
+		Topic:     "message",
+		Partition: 1,
+		Offset:    7,
+		Value:     []byte(`{"a":1}`),
+		Headers:   []*sarama.RecordHeader{{Key: []byte("traceID"), Value: []byte("abc")}},
+	})
+	ms := b.Messages("message.retry")
+	if count != 3 || len(ms) != 1 {
+		t.Errorf("%s has err[%d %d] should [3 1]", t.Name(), count, len(ms))
+		return
+	}
+	m := ms[0]
+	if string(m.Value) != `{"a":1}` ||
+		m.Header.Value("traceID") != "abc" ||
+		m.Header.Value(queue.HeaderAttempt) != "3" ||
+		m.Header.Value(queue.HeaderRetry) != "1" ||
+		m.Header.Value(queue.HeaderError) != "biz error" ||
+		m.Header.Value(queue.HeaderSourceOffset) != "7" {
+		t.Errorf("%s has err[%s %+v]", t.Name(), m.Value, m.Header)
+	}
+
+	// from the retry topic, it is handled after the delay.
+	begin := time.Now()
+	rm := &sarama.ConsumerMessage{Topic: "message.retry", Value: m.Value}
+	for k, v := range m.Header {
+		rm.Headers = append(rm.Headers, &sarama.RecordHeader{Key: []byte(k), Value: []byte(v)})
+	}
+	process(rm)
+	if time.Since(begin) < 5*time.Millisecond {
+		t.Errorf("%s has err[%v] should [delay]", t.Name(), time.Since(begin))
+	}
+	ms = b.Messages("message.dlq")
+	if count != 6 || len(ms) != 1 {
+		t.Errorf("%s has err[%d %d] should [6 1]", t.Name(), count, len(ms))
+		return
+	}
+	m = ms[0]
+	if m.Header.Value(queue.HeaderAttempt) != "6" ||
+		m.Header.Value(queue.HeaderRetryAt) != "" ||
+		m.Header.Value(queue.HeaderSourceTopic) != "message" ||
+		m.Header.Value("traceID") != "abc" {
+		t.Errorf("%s has err[%+v]", t.Name(), m.Header)
+	}
+	fmt.Println(t.Name())
+}
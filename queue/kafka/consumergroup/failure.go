@@ -0,0 +1,150 @@
+package consumergroup
+
+/*
+ * @abstract failure policy of consumer: retry in place, retry topics and dead letter
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+
+	"github.com/neo532/gokit/queue"
+)
+
+type retryTopic struct {
+	producer queue.Producer
+	delay    time.Duration
+}
+
+// failure is the policy of the message failed in handler.
+// It retries in place with backoff, then forwards to the retry topics one by one,
+// and finally to the dead letter topic. Without both the message is dropped after logging.
+type failure struct {
+	retries     int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	retryTopics []retryTopic
+	deadLetter  queue.Producer
+}
+
+// process runs hdl by the failure policy, ok is false if c is done before the message is handled,
+// then the message should not be marked.
+func (h *groupHandler) process(c context.Context, m *sarama.ConsumerMessage, hdl queue.ConsumerHandler) (ok bool, err error) {
+	header, _ := queue.GetHeaderFromContext(c)
+	if !h.delay(c, header) {
+		return
+	}
+
+	attempt, _ := strconv.Atoi(header.Value(queue.HeaderAttempt))
+	backoff := h.failure.backoff
+	for i := 0; ; i++ {
+		attempt++
+		if err = hdl(c, m.Value); err == nil {
+			ok = true
+			return
+		}
+		if i >= h.failure.retries {
+			break
+		}
+
+		h.logger.Warn(c, "Handler will retry!",
+			queue.KeyName, h.name,
+			queue.KeyTopic, m.Topic,
+			queue.KeyPartition, m.Partition,
+			queue.KeyOffset, m.Offset,
+			queue.KeyErr, err,
+			"attempt", attempt,
+		)
+		if !sleep(c, backoff) {
+			return
+		}
+		if backoff *= 2; backoff > h.failure.maxBackoff {
+			backoff = h.failure.maxBackoff
+		}
+	}
+
+	ok = h.forward(c, m, header, attempt, err)
+	return
+}
+
+// delay waits until the time in queue.HeaderRetryAt of the message from the retry topic.
+func (h *groupHandler) delay(c context.Context, header queue.Header) bool {
+	at, err := strconv.ParseInt(header.Value(queue.HeaderRetryAt), 10, 64)
+	if err != nil {
+		return true
+	}
+	return sleep(c, time.Until(time.UnixMilli(at)))
+}
+
+// forward sends the failed message to the next retry topic or the dead letter topic,
+// it retries until successful or c is done, so that the message is never lost.
+func (h *groupHandler) forward(c context.Context, m *sarama.ConsumerMessage, header queue.Header, attempt int, err error) (ok bool) {
+	fh := make(queue.Header, len(header)+7)
+	for k, v := range header {
+		fh[k] = v
+	}
+	delete(fh, queue.HeaderRetryAt)
+
+	var pdc queue.Producer
+	retry, _ := strconv.Atoi(header.Value(queue.HeaderRetry))
+	switch {
+	case retry < len(h.failure.retryTopics):
+		rt := h.failure.retryTopics[retry]
+		pdc = rt.producer
+		fh.Set(queue.HeaderRetry, strconv.Itoa(retry+1))
+		fh.Set(queue.HeaderRetryAt, strconv.FormatInt(time.Now().Add(rt.delay).UnixMilli(), 10))
+	case h.failure.deadLetter != nil:
+		pdc = h.failure.deadLetter
+	default:
+		return true
+	}
+
+	fh.Set(queue.HeaderError, err.Error())
+	fh.Set(queue.HeaderAttempt, strconv.Itoa(attempt))
+	if fh.Value(queue.HeaderSourceTopic) == "" {
+		fh.Set(queue.HeaderSourceTopic, m.Topic)
+		fh.Set(queue.HeaderSourcePartition, strconv.FormatInt(int64(m.Partition), 10))
+		fh.Set(queue.HeaderSourceOffset, strconv.FormatInt(m.Offset, 10))
+	}
+
+	backoff := h.failure.backoff
+	for {
+		e := pdc.Send(queue.WithForwardHeader(c, fh), queue.Encoded(m.Value))
+		if e == nil {
+			return true
+		}
+		h.logger.Error(c, "Forwarding has error!",
+			queue.KeyName, h.name,
+			queue.KeyTopic, m.Topic,
+			queue.KeyPartition, m.Partition,
+			queue.KeyOffset, m.Offset,
+			queue.KeyErr, e,
+		)
+		if !sleep(c, backoff) {
+			return false
+		}
+		if backoff *= 2; backoff > h.failure.maxBackoff {
+			backoff = h.failure.maxBackoff
+		}
+	}
+}
+
+// sleep returns false if c is done within d.
+func sleep(c context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return c.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-c.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
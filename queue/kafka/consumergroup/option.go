@@ -71,3 +71,28 @@ func WithMiddleware(ms ...queue.ConsumerMiddleware) Option {
 		o.handler.middleware = append(o.handler.middleware, ms...)
 	}
 }
+
+// WithRetry retries the failed message in place for count times,
+// the backoff starts from backoff and doubles up to maxBackoff.
+func WithRetry(count int, backoff, maxBackoff time.Duration) Option {
+	return func(o *ConsumerGroup) {
+		o.handler.failure.retries = count
+		o.handler.failure.backoff = backoff
+		o.handler.failure.maxBackoff = maxBackoff
+	}
+}
+
+// WithRetryTopic appends a retry topic, the failed message is forwarded to the retry topics one by one
+// and is handled after delay. The consumer should also consume the retry topics by WithTopics.
+func WithRetryTopic(pdc queue.Producer, delay time.Duration) Option {
+	return func(o *ConsumerGroup) {
+		o.handler.failure.retryTopics = append(o.handler.failure.retryTopics, retryTopic{producer: pdc, delay: delay})
+	}
+}
+
+// WithDeadLetter forwards the message to the dead letter topic after all retries failed.
+func WithDeadLetter(pdc queue.Producer) Option {
+	return func(o *ConsumerGroup) {
+		o.handler.failure.deadLetter = pdc
+	}
+}
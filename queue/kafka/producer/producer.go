@@ -158,7 +158,7 @@ func (pdc *Producer) Send(c context.Context, message any) (err error) {
 	}
 
 	var msg []byte
-	if msg, err = pdc.encoder(message); err != nil {
+	if msg, err = queue.Encode(message, pdc.encoder); err != nil {
 		ps = append(ps, queue.KeyErr, err, queue.KeyMessage, message)
 		pdc.logger.Error(c, "Producer's encoder Has err!", ps...)
 		return
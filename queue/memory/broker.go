@@ -0,0 +1,222 @@
+package memory
+
+/*
+ * @abstract in-process broker of topics and consumer groups
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/neo532/gokit/queue"
+)
+
+// Message is a message in a topic.
+type Message struct {
+	Topic     string
+	Offset    int64
+	Value     []byte
+	Header    queue.Header
+	Timestamp time.Time
+}
+
+// Delivery is a message delivered to a group, which should be acked or nacked.
+type Delivery struct {
+	*Message
+	Group   string
+	Attempt int
+
+	broker *Broker
+}
+
+// Ack marks the delivery as consumed.
+func (d *Delivery) Ack() {
+	d.broker.ack(d)
+}
+
+// Nack returns the delivery to the group, which is redelivered after delay.
+func (d *Delivery) Nack(delay time.Duration) {
+	d.broker.nack(d, delay)
+}
+
+type retry struct {
+	offset  int64
+	attempt int
+	at      time.Time
+}
+
+type group struct {
+	next     int64
+	retries  []retry
+	inflight map[int64]int
+}
+
+type topic struct {
+	messages []*Message
+	groups   map[string]*group
+}
+
+// Broker is an in-process broker, every group of a topic receives all the messages of it at least once.
+type Broker struct {
+	lock    sync.Mutex
+	topics  map[string]*topic
+	changed chan struct{}
+}
+
+// NewBroker returns a instance of Broker.
+func NewBroker() *Broker {
+	return &Broker{
+		topics:  make(map[string]*topic),
+		changed: make(chan struct{}),
+	}
+}
+
+// Publish appends the message to topic and returns the offset of it.
+func (b *Broker) Publish(topicName string, value []byte, h queue.Header) (offset int64) {
+	header := make(queue.Header, len(h))
+	for k, v := range h {
+		header[k] = v
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	t := b.topic(topicName)
+	offset = int64(len(t.messages))
+	t.messages = append(t.messages, &Message{
+		Topic:     topicName,
+		Offset:    offset,
+		Value:     append([]byte(nil), value...),
+		Header:    header,
+		Timestamp: time.Now(),
+	})
+	b.notify()
+	return
+}
+
+// Messages returns the messages of topic.
+func (b *Broker) Messages(topicName string) (ms []Message) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	t, ok := b.topics[topicName]
+	if !ok {
+		return
+	}
+	ms = make([]Message, 0, len(t.messages))
+	for _, m := range t.messages {
+		ms = append(ms, *m)
+	}
+	return
+}
+
+// Fetch returns the next delivery of topics for group, it blocks until one is available or c is done.
+// The new group starts from the oldest message.
+func (b *Broker) Fetch(c context.Context, groupName string, topics ...string) (d *Delivery, err error) {
+	for {
+		b.lock.Lock()
+		now := time.Now()
+		var wake time.Time
+		for _, name := range topics {
+			var at time.Time
+			if d, at = b.next(name, groupName, now); d != nil {
+				b.lock.Unlock()
+				return
+			}
+			if !at.IsZero() && (wake.IsZero() || at.Before(wake)) {
+				wake = at
+			}
+		}
+		changed := b.changed
+		b.lock.Unlock()
+
+		var t *time.Timer
+		var timer <-chan time.Time
+		if !wake.IsZero() {
+			t = time.NewTimer(time.Until(wake))
+			timer = t.C
+		}
+		select {
+		case <-changed:
+		case <-timer:
+		case <-c.Done():
+			err = c.Err()
+		}
+		if t != nil {
+			t.Stop()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// next returns the due retry or the new message of topic for group,
+// or the time of the earliest retry.
+func (b *Broker) next(topicName, groupName string, now time.Time) (d *Delivery, wake time.Time) {
+	t := b.topic(topicName)
+	g, ok := t.groups[groupName]
+	if !ok {
+		g = &group{inflight: make(map[int64]int)}
+		t.groups[groupName] = g
+	}
+
+	for i, r := range g.retries {
+		if r.at.After(now) {
+			if wake.IsZero() || r.at.Before(wake) {
+				wake = r.at
+			}
+			continue
+		}
+		g.retries = append(g.retries[:i], g.retries[i+1:]...)
+		g.inflight[r.offset] = r.attempt + 1
+		d = &Delivery{Message: t.messages[r.offset], Group: groupName, Attempt: r.attempt + 1, broker: b}
+		return
+	}
+
+	if g.next < int64(len(t.messages)) {
+		offset := g.next
+		g.next++
+		g.inflight[offset] = 1
+		d = &Delivery{Message: t.messages[offset], Group: groupName, Attempt: 1, broker: b}
+	}
+	return
+}
+
+func (b *Broker) ack(d *Delivery) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	delete(b.topic(d.Topic).groups[d.Group].inflight, d.Offset)
+}
+
+func (b *Broker) nack(d *Delivery, delay time.Duration) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	g := b.topic(d.Topic).groups[d.Group]
+	attempt, ok := g.inflight[d.Offset]
+	if !ok {
+		return
+	}
+	delete(g.inflight, d.Offset)
+	g.retries = append(g.retries, retry{offset: d.Offset, attempt: attempt, at: time.Now().Add(delay)})
+	b.notify()
+}
+
+func (b *Broker) topic(name string) *topic {
+	t, ok := b.topics[name]
+	if !ok {
+		t = &topic{groups: make(map[string]*group)}
+		b.topics[name] = t
+	}
+	return t
+}
+
+// notify wakes up all the fetchers.
+func (b *Broker) notify() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
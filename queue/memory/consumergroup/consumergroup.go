@@ -0,0 +1,237 @@
+package consumergroup
+
+/*
+ * @abstract consumer group of the in-process broker
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/neo532/gokit/logger"
+	"github.com/neo532/gokit/queue"
+	"github.com/neo532/gokit/queue/memory"
+)
+
+var _ queue.Consumer = (*ConsumerGroup)(nil)
+
+// ConsumerGroup consumes the topics of memory.Broker, as a drop-in for kafka in tests.
+// The message failed in handler is redelivered to the group, so it is consumed at least once,
+// until WithMaxAttempts is reached, then it is sent to WithDeadLetter or dropped.
+type ConsumerGroup struct {
+	name   string
+	broker *memory.Broker
+	group  string
+	topics []string
+
+	handler         func(ctx context.Context, message []byte) (err error)
+	middleware      []queue.ConsumerMiddleware
+	goCount         int
+	slowTime        time.Duration
+	redeliveryDelay time.Duration
+	maxAttempts     int
+	deadLetter      queue.Producer
+	logger          logger.ILogger
+
+	lock   sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewGroup(name string, broker *memory.Broker, group string, opts ...Option) (csm *ConsumerGroup, err error) {
+	csm = &ConsumerGroup{
+		name:            name,
+		broker:          broker,
+		group:           group,
+		goCount:         1,
+		slowTime:        3 * time.Second,
+		redeliveryDelay: 100 * time.Millisecond,
+		logger:          logger.NewDefaultILogger(),
+		middleware:      make([]queue.ConsumerMiddleware, 0, 1),
+	}
+	for _, o := range opts {
+		o(csm)
+	}
+
+	switch {
+	case csm.broker == nil:
+		err = errors.New("Nil broker!")
+	case csm.handler == nil:
+		err = errors.New("Nil handler!")
+	case len(csm.topics) == 0:
+		err = errors.New("Empty topics!")
+	}
+	if err != nil {
+		csm.logger.Error(context.Background(), "NewGroup has error!",
+			queue.KeyName, csm.name,
+			queue.KeyErr, err,
+		)
+	}
+	if csm.goCount < 1 {
+		csm.goCount = 1
+	}
+	return
+}
+
+func (csm *ConsumerGroup) Name() (name string) {
+	return csm.name
+}
+
+// Stop stops consuming and waits for the running handlers.
+func (csm *ConsumerGroup) Stop(c context.Context) (err error) {
+	csm.lock.Lock()
+	cancel, done := csm.cancel, csm.done
+	csm.lock.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-c.Done():
+		err = c.Err()
+	}
+	return
+}
+
+// Start blocks until c is done or Stop is called, it can be started again after returned.
+func (csm *ConsumerGroup) Start(c context.Context) (err error) {
+	csm.lock.Lock()
+	if csm.cancel != nil {
+		csm.lock.Unlock()
+		return errors.New("Consumer has been started!")
+	}
+	c, csm.cancel = context.WithCancel(c)
+	csm.done = make(chan struct{})
+	cancel, done := csm.cancel, csm.done
+	csm.lock.Unlock()
+	defer func() {
+		cancel()
+		csm.lock.Lock()
+		csm.cancel, csm.done = nil, nil
+		csm.lock.Unlock()
+		close(done)
+	}()
+
+	csm.logger.Info(c, "Consumer is starting!",
+		queue.KeyName, csm.name,
+		queue.KeyTopic, strings.Join(csm.topics, ","),
+		queue.KeyGroup, csm.group,
+	)
+
+	hdl := func(c context.Context, message []byte) (err error) {
+		return csm.handler(c, message)
+	}
+	if len(csm.middleware) > 0 {
+		hdl = queue.ChainConsumer(csm.middleware...)(hdl)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(csm.goCount)
+	for i := 0; i < csm.goCount; i++ {
+		go func() {
+			defer wg.Done()
+			for {
+				d, err := csm.broker.Fetch(c, csm.group, csm.topics...)
+				if err != nil {
+					return
+				}
+				csm.consume(c, d, hdl)
+			}
+		}()
+	}
+	wg.Wait()
+
+	csm.logger.Info(c, "topic consumer have canceled!",
+		queue.KeyTopic, csm.topics,
+	)
+	return
+}
+
+// consume acks the delivery if the handler returns nil, or nacks it to be redelivered.
+func (csm *ConsumerGroup) consume(c context.Context, d *memory.Delivery, hdl queue.ConsumerHandler) {
+	ps := []any{
+		queue.KeyName, csm.name,
+		queue.KeyTopic, d.Topic,
+		queue.KeyOffset, d.Offset,
+		queue.KeyMessage, string(d.Value),
+		"attempt", d.Attempt,
+	}
+
+	c = queue.InitHeaderToContext(c)
+	if header, ok := queue.GetHeaderFromContext(c); ok {
+		for k, v := range d.Header {
+			header.Set(k, v)
+		}
+	}
+
+	begin := time.Now()
+	err := func() (err error) {
+		defer func() {
+			if e := recover(); e != nil {
+				err = fmt.Errorf("panic: %v", e)
+				ps = append(ps, queue.KeyStack, string(debug.Stack()))
+			}
+		}()
+		return hdl(c, d.Value)
+	}()
+	cost := time.Since(begin)
+	ps = append(ps, "cost", cost)
+
+	if err != nil {
+		ps = append(ps, queue.KeyErr, err)
+		csm.logger.Error(c, "Consumer's Has err!", ps...)
+		csm.fail(c, d, err, ps)
+		return
+	}
+	d.Ack()
+
+	// slow
+	if cost > csm.slowTime {
+		ps = append(ps,
+			"slowTime", csm.slowTime,
+		)
+		csm.logger.Warn(c, "slowlog", ps...)
+		return
+	}
+
+	csm.logger.Info(c, "", ps...)
+}
+
+// fail nacks the delivery to be redelivered, or sends it to the dead letter after the max attempts.
+func (csm *ConsumerGroup) fail(c context.Context, d *memory.Delivery, err error, ps []any) {
+	if csm.maxAttempts <= 0 || d.Attempt < csm.maxAttempts {
+		d.Nack(csm.redeliveryDelay)
+		return
+	}
+	if csm.deadLetter == nil {
+		d.Ack()
+		csm.logger.Error(c, "Message is dropped after max attempts!", ps...)
+		return
+	}
+
+	fh := make(queue.Header, len(d.Header)+4)
+	for k, v := range d.Header {
+		fh[k] = v
+	}
+	fh.Set(queue.HeaderError, err.Error())
+	fh.Set(queue.HeaderAttempt, strconv.Itoa(d.Attempt))
+	fh.Set(queue.HeaderSourceTopic, d.Topic)
+	fh.Set(queue.HeaderSourceOffset, strconv.FormatInt(d.Offset, 10))
+
+	if e := csm.deadLetter.Send(queue.WithForwardHeader(c, fh), queue.Encoded(d.Value)); e != nil {
+		d.Nack(csm.redeliveryDelay)
+		csm.logger.Error(c, "Forwarding has error!", append(ps, "forwardErr", e)...)
+		return
+	}
+	d.Ack()
+}
@@ -0,0 +1,216 @@
+package consumergroup
+
+/*
+ * @abstract consumer group of the in-process broker
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neo532/gokit/queue"
+	"github.com/neo532/gokit/queue/memory"
+	"github.com/neo532/gokit/queue/memory/producer"
+)
+
+func TraceID() queue.ProducerMiddleware {
+	return func(handler queue.ProducerHandler) queue.ProducerHandler {
+		return func(c context.Context, message any) (err error) {
+			c = queue.AppendHeaderToContext(c, "traceID", "abc")
+			return handler(c, message)
+		}
+	}
+}
+
+func consume(t *testing.T, csm *ConsumerGroup, wait func()) {
+	go func() {
+		if err := csm.Start(context.Background()); err != nil {
+			t.Errorf("%s has err[%+v]", t.Name(), err)
+		}
+	}()
+	wait()
+	if err := csm.Stop(context.Background()); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+	}
+}
+
+func TestConsumer(t *testing.T) {
+	b := memory.NewBroker()
+	pdc := producer.New("default", b, producer.WithTopic("message"), producer.WithMiddleware(TraceID()))
+	if err := pdc.Send(context.Background(), "hello"); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+		return
+	}
+
+	var traceID, message string
+	done := make(chan struct{})
+	csm, err := NewGroup(
+		"default",
+		b,
+		"sender",
+		WithTopics("message"),
+		WithHandler(func(c context.Context, msg []byte) (err error) {
+			message = string(msg)
+			close(done)
+			return
+		}),
+		WithMiddleware(func(handler queue.ConsumerHandler) queue.ConsumerHandler {
+			return func(c context.Context, msg []byte) (err error) {
+				if h, ok := queue.GetHeaderFromContext(c); ok {
+					traceID = h.Value("traceID")
+				}
+				return handler(c, msg)
+			}
+		}),
+	)
+	if err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+		return
+	}
+	consume(t, csm, func() { <-done })
+
+	if traceID != "abc" || message != `"hello"` {
+		t.Errorf("%s has err[%s %s] should [abc \"hello\"]", t.Name(), traceID, message)
+	}
+	fmt.Println(t.Name())
+}
+
+func TestRedelivery(t *testing.T) {
+	b := memory.NewBroker()
+	pdc := producer.New("default", b, producer.WithTopic("message"))
+	pdc.Send(context.Background(), "hello")
+
+	var count int
+	done := make(chan struct{})
+	csm, _ := NewGroup(
+		"default",
+		b,
+		"sender",
+		WithTopics("message"),
+		WithRedeliveryDelay(time.Millisecond),
+		WithHandler(func(c context.Context, msg []byte) (err error) {
+			if count++; count < 3 {
+				return errors.New("biz error")
+			}
+			close(done)
+			return
+		}),
+	)
+	consume(t, csm, func() { <-done })
+
+	if count != 3 {
+		t.Errorf("%s has err[%d] should [3]", t.Name(), count)
+	}
+	fmt.Println(t.Name())
+}
+
+func TestGroups(t *testing.T) {
+	b := memory.NewBroker()
+	pdc := producer.New("default", b, producer.WithTopic("message"))
+	for i := 0; i < 10; i++ {
+		pdc.Send(context.Background(), i)
+	}
+
+	var lock sync.Mutex
+	counts := make(map[string]int)
+	var wg sync.WaitGroup
+	wg.Add(20)
+	for _, group := range []string{"a", "b"} {
+		csm, _ := NewGroup(
+			"default",
+			b,
+			group,
+			WithTopics("message"),
+			WithGoCount(3),
+			WithHandler(func(c context.Context, msg []byte) (err error) {
+				lock.Lock()
+				counts[group]++
+				lock.Unlock()
+				wg.Done()
+				return
+			}),
+		)
+		go csm.Start(context.Background())
+		defer csm.Stop(context.Background())
+	}
+	wg.Wait()
+
+	if counts["a"] != 10 || counts["b"] != 10 {
+		t.Errorf("%s has err[%+v] should [10 10]", t.Name(), counts)
+	}
+	fmt.Println(t.Name())
+}
+
+func TestDeadLetter(t *testing.T) {
+	b := memory.NewBroker()
+	pdc := producer.New("default", b, producer.WithTopic("message"), producer.WithMiddleware(TraceID()))
+	pdc.Send(context.Background(), "hello")
+
+	var count int
+	csm, _ := NewGroup(
+		"default",
+		b,
+		"sender",
+		WithTopics("message"),
+		WithRedeliveryDelay(time.Millisecond),
+		WithMaxAttempts(2),
+		WithDeadLetter(producer.New("dead", b, producer.WithTopic("dead"))),
+		WithHandler(func(c context.Context, msg []byte) (err error) {
+			count++
+			return errors.New("biz error")
+		}),
+	)
+	consume(t, csm, func() {
+		for len(b.Messages("dead")) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+	})
+
+	ms := b.Messages("dead")
+	if count != 2 || len(ms) != 1 {
+		t.Errorf("%s has err[%d %d] should [2 1]", t.Name(), count, len(ms))
+		return
+	}
+	m := ms[0]
+	if string(m.Value) != `"hello"` ||
+		m.Header.Value("traceID") != "abc" ||
+		m.Header.Value(queue.HeaderError) != "biz error" ||
+		m.Header.Value(queue.HeaderAttempt) != "2" ||
+		m.Header.Value(queue.HeaderSourceTopic) != "message" ||
+		m.Header.Value(queue.HeaderSourceOffset) != "0" {
+		t.Errorf("%s has err[%s %+v]", t.Name(), m.Value, m.Header)
+	}
+	fmt.Println(t.Name())
+}
+
+func TestRestart(t *testing.T) {
+	b := memory.NewBroker()
+	pdc := producer.New("default", b, producer.WithTopic("message"))
+
+	received := make(chan string, 1)
+	csm, _ := NewGroup(
+		"default",
+		b,
+		"sender",
+		WithTopics("message"),
+		WithHandler(func(c context.Context, msg []byte) (err error) {
+			received <- string(msg)
+			return
+		}),
+	)
+	for _, message := range []string{"first", "second"} {
+		pdc.Send(context.Background(), message)
+		var got string
+		consume(t, csm, func() { got = <-received })
+		if got != `"`+message+`"` {
+			t.Errorf("%s has err[%s] should [%q]", t.Name(), got, message)
+		}
+	}
+	fmt.Println(t.Name())
+}
@@ -0,0 +1,71 @@
+package consumergroup
+
+/*
+ * @abstract consumer's option
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"time"
+
+	"github.com/neo532/gokit/logger"
+	"github.com/neo532/gokit/queue"
+)
+
+type Option func(o *ConsumerGroup)
+
+func WithLogger(l logger.ILogger) Option {
+	return func(o *ConsumerGroup) {
+		o.logger = l
+	}
+}
+func WithSlowLog(t time.Duration) Option {
+	return func(o *ConsumerGroup) {
+		o.slowTime = t
+	}
+}
+func WithGoCount(count int) Option {
+	return func(o *ConsumerGroup) {
+		o.goCount = count
+	}
+}
+func WithTopics(s ...string) Option {
+	return func(o *ConsumerGroup) {
+		o.topics = s
+	}
+}
+func WithHandler(fn func(ctx context.Context, message []byte) (err error)) Option {
+	return func(o *ConsumerGroup) {
+		o.handler = fn
+	}
+}
+
+// WithRedeliveryDelay sets the delay of redelivering the message failed in handler.
+func WithRedeliveryDelay(t time.Duration) Option {
+	return func(o *ConsumerGroup) {
+		o.redeliveryDelay = t
+	}
+}
+
+// WithMaxAttempts sets the max attempts of the message failed in handler, including the first one.
+// It is redelivered without limit by default.
+func WithMaxAttempts(n int) Option {
+	return func(o *ConsumerGroup) {
+		o.maxAttempts = n
+	}
+}
+
+// WithDeadLetter sends the message failed after WithMaxAttempts to p with the original headers
+// and the error, attempt and source headers, it is dropped after logging without p.
+func WithDeadLetter(p queue.Producer) Option {
+	return func(o *ConsumerGroup) {
+		o.deadLetter = p
+	}
+}
+func WithMiddleware(ms ...queue.ConsumerMiddleware) Option {
+	return func(o *ConsumerGroup) {
+		o.middleware = append(o.middleware, ms...)
+	}
+}
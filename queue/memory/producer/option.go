@@ -0,0 +1,39 @@
+package producer
+
+/*
+ * @abstract producer's option
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"github.com/neo532/gokit/logger"
+	"github.com/neo532/gokit/queue"
+)
+
+// ========== Option ==========
+type Option func(*Producer)
+
+func WithLogger(l logger.ILogger) Option {
+	return func(o *Producer) {
+		o.logger = l
+	}
+}
+
+func WithTopic(topic string) Option {
+	return func(o *Producer) {
+		o.Topic = topic
+	}
+}
+
+func WithEncoder(fn EncodeMessageFunc) Option {
+	return func(o *Producer) {
+		o.encoder = fn
+	}
+}
+
+func WithMiddleware(ms ...queue.ProducerMiddleware) Option {
+	return func(o *Producer) {
+		o.middleware = append(o.middleware, ms...)
+	}
+}
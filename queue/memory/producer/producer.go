@@ -0,0 +1,103 @@
+package producer
+
+/*
+ * @abstract producer of the in-process broker
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/neo532/gokit/logger"
+	"github.com/neo532/gokit/queue"
+	"github.com/neo532/gokit/queue/memory"
+)
+
+var _ queue.Producer = (*Producer)(nil)
+
+type EncodeMessageFunc func(message any) (msg []byte, err error)
+
+func JsonMessageEncoder(message any) (msg []byte, err error) {
+	return json.Marshal(message)
+}
+
+// Producer sends the messages to a topic of memory.Broker, as a drop-in for kafka in tests.
+type Producer struct {
+	Name  string
+	Topic string
+
+	broker     *memory.Broker
+	logger     logger.ILogger
+	encoder    EncodeMessageFunc
+	err        error
+	middleware []queue.ProducerMiddleware
+}
+
+func New(name string, broker *memory.Broker, opts ...Option) (pdc *Producer) {
+	pdc = &Producer{
+		Name:       name,
+		broker:     broker,
+		logger:     logger.NewDefaultILogger(),
+		encoder:    JsonMessageEncoder,
+		middleware: make([]queue.ProducerMiddleware, 0, 1),
+	}
+	for _, o := range opts {
+		o(pdc)
+	}
+	if pdc.broker == nil {
+		pdc.err = errors.New("Nil broker!")
+	}
+	return
+}
+
+func (pdc *Producer) Error() error {
+	return pdc.err
+}
+
+func (pdc *Producer) Send(c context.Context, message any) (err error) {
+	if err = pdc.err; err != nil {
+		return
+	}
+
+	c = queue.InitHeaderToContext(c)
+
+	ps := []any{
+		queue.KeyName, pdc.Name,
+		queue.KeyTopic, pdc.Topic,
+	}
+
+	var msg []byte
+	if msg, err = queue.Encode(message, pdc.encoder); err != nil {
+		ps = append(ps, queue.KeyErr, err, queue.KeyMessage, message)
+		pdc.logger.Error(c, "Producer's encoder Has err!", ps...)
+		return
+	}
+	ps = append(ps, queue.KeyMessage, string(msg))
+
+	h := func(c context.Context, message any) (err error) {
+		header, _ := queue.GetHeaderFromContext(c)
+		o := pdc.broker.Publish(pdc.Topic, msg, header)
+		ps = append(ps, queue.KeyOffset, o)
+		return
+	}
+
+	if len(pdc.middleware) > 0 {
+		h = queue.ChainProducer(pdc.middleware...)(h)
+	}
+
+	if err = h(c, message); err != nil {
+		ps = append(ps, queue.KeyErr, err)
+		pdc.logger.Error(c, "Producer's sending Has err!", ps...)
+		return
+	}
+
+	pdc.logger.Info(c, "Producer have been delivered!", ps...)
+	return
+}
+
+func (pdc *Producer) Close() func() {
+	return func() {}
+}
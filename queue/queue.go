@@ -21,3 +21,16 @@ type Consumer interface {
 	Stop(context.Context) error
 	Name() string
 }
+
+// Encoded is the message encoded already, such as the one parked or forwarded,
+// the producers send it unchanged without their encoders.
+type Encoded []byte
+
+// Encode returns the bytes of message by encoder, or the Encoded one unchanged.
+func Encode(message any, encoder func(message any) ([]byte, error)) (msg []byte, err error) {
+	if e, ok := message.(Encoded); ok {
+		msg = e
+		return
+	}
+	return encoder(message)
+}
@@ -0,0 +1,341 @@
+package consumergroup
+
+/*
+ * @abstract consumer group of redis stream
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	dbredis "github.com/neo532/gokit/database/redis"
+	"github.com/neo532/gokit/logger"
+	"github.com/neo532/gokit/queue"
+	qredis "github.com/neo532/gokit/queue/redis"
+)
+
+var _ queue.Consumer = (*ConsumerGroup)(nil)
+
+// ConsumerGroup consumes the redis streams by XREADGROUP and XACKs after the handler returns nil.
+// The failed entry stays pending, and is claimed by XAUTOCLAIM after minIdle, so it is consumed at least once.
+type ConsumerGroup struct {
+	name     string
+	rdbs     *dbredis.Rediss
+	group    string
+	topics   []string
+	consumer string
+
+	handler    func(ctx context.Context, message []byte) (err error)
+	middleware []queue.ConsumerMiddleware
+	goCount    int
+	count      int64
+	block      time.Duration
+	minIdle    time.Duration
+	slowTime   time.Duration
+	logger     logger.ILogger
+
+	lock   sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewGroup(name string, rdbs *dbredis.Rediss, group string, opts ...Option) (csm *ConsumerGroup, err error) {
+	hostname, _ := os.Hostname()
+	csm = &ConsumerGroup{
+		name:       name,
+		rdbs:       rdbs,
+		group:      group,
+		consumer:   hostname + "-" + strconv.Itoa(os.Getpid()),
+		goCount:    1,
+		count:      10,
+		block:      time.Second,
+		minIdle:    time.Minute,
+		slowTime:   3 * time.Second,
+		logger:     logger.NewDefaultILogger(),
+		middleware: make([]queue.ConsumerMiddleware, 0, 1),
+	}
+	for _, o := range opts {
+		o(csm)
+	}
+
+	switch {
+	case csm.rdbs == nil:
+		err = errors.New("Nil redis!")
+	case csm.rdbs.Error() != nil:
+		err = csm.rdbs.Error()
+	case csm.handler == nil:
+		err = errors.New("Nil handler!")
+	case len(csm.topics) == 0:
+		err = errors.New("Empty topics!")
+	}
+	if err != nil {
+		csm.logger.Error(context.Background(), "NewGroup has error!",
+			queue.KeyName, csm.name,
+			queue.KeyErr, err,
+		)
+	}
+	if csm.goCount < 1 {
+		csm.goCount = 1
+	}
+	return
+}
+
+func (csm *ConsumerGroup) Name() (name string) {
+	return csm.name
+}
+
+// Stop stops consuming and waits for the running handlers.
+func (csm *ConsumerGroup) Stop(c context.Context) (err error) {
+	csm.lock.Lock()
+	cancel, done := csm.cancel, csm.done
+	csm.lock.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-c.Done():
+		err = c.Err()
+	}
+	return
+}
+
+// Start creates the groups if not exist, and blocks until c is done or Stop is called,
+// it can be started again after returned.
+func (csm *ConsumerGroup) Start(c context.Context) (err error) {
+	csm.lock.Lock()
+	if csm.cancel != nil {
+		csm.lock.Unlock()
+		return errors.New("Consumer has been started!")
+	}
+	c, csm.cancel = context.WithCancel(c)
+	csm.done = make(chan struct{})
+	cancel, done := csm.cancel, csm.done
+	csm.lock.Unlock()
+	defer func() {
+		cancel()
+		csm.lock.Lock()
+		csm.cancel, csm.done = nil, nil
+		csm.lock.Unlock()
+		close(done)
+	}()
+
+	ps := []any{
+		queue.KeyName, csm.name,
+		queue.KeyTopic, strings.Join(csm.topics, ","),
+		queue.KeyGroup, csm.group,
+	}
+	for _, topic := range csm.topics {
+		// from the oldest entry as the new group of kafka's earliest.
+		if err = csm.rdbs.Rdb(c).XGroupCreateMkStream(c, topic, csm.group, "0").Err(); err != nil &&
+			!strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			ps = append(ps, queue.KeyErr, err)
+			csm.logger.Error(c, "XGroupCreate has error!", ps...)
+			return
+		}
+		err = nil
+	}
+	csm.logger.Info(c, "Consumer is starting!", ps...)
+
+	hdl := func(c context.Context, message []byte) (err error) {
+		return csm.handler(c, message)
+	}
+	if len(csm.middleware) > 0 {
+		hdl = queue.ChainConsumer(csm.middleware...)(hdl)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(csm.goCount + 1)
+	for i := 0; i < csm.goCount; i++ {
+		go func(consumer string) {
+			defer wg.Done()
+			csm.read(c, consumer, hdl)
+		}(csm.consumer + "-" + strconv.Itoa(i))
+	}
+	go func() {
+		defer wg.Done()
+		csm.claim(c, csm.consumer+"-claim", hdl)
+	}()
+	wg.Wait()
+
+	csm.logger.Info(c, "topic consumer have canceled!",
+		queue.KeyTopic, csm.topics,
+	)
+	return
+}
+
+// read consumes the new entries by XREADGROUP.
+func (csm *ConsumerGroup) read(c context.Context, consumer string, hdl queue.ConsumerHandler) {
+	streams := make([]string, 0, len(csm.topics)*2)
+	streams = append(streams, csm.topics...)
+	for range csm.topics {
+		streams = append(streams, ">")
+	}
+
+	for c.Err() == nil {
+		rst, err := csm.rdbs.Rdb(c).XReadGroup(c, &redis.XReadGroupArgs{
+			Group:    csm.group,
+			Consumer: consumer,
+			Streams:  streams,
+			Count:    csm.count,
+			Block:    csm.block,
+		}).Result()
+		if err != nil {
+			if err != redis.Nil && c.Err() == nil {
+				csm.logger.Error(c, "XReadGroup has error!",
+					queue.KeyName, csm.name,
+					queue.KeyGroup, csm.group,
+					queue.KeyErr, err,
+				)
+				sleep(c, time.Second)
+			}
+			continue
+		}
+		for _, s := range rst {
+			for _, m := range s.Messages {
+				csm.consume(c, s.Stream, m, hdl)
+			}
+		}
+	}
+}
+
+// claim consumes the pending entries idle for longer than minIdle by XAUTOCLAIM.
+func (csm *ConsumerGroup) claim(c context.Context, consumer string, hdl queue.ConsumerHandler) {
+	interval := csm.minIdle / 2
+	if interval < time.Second {
+		interval = time.Second
+	}
+	for sleep(c, interval) {
+		for _, topic := range csm.topics {
+			start := "0-0"
+			for c.Err() == nil {
+				ms, next, err := csm.xAutoClaim(c, topic, consumer, start)
+				if err != nil {
+					if c.Err() == nil {
+						csm.logger.Error(c, "XAutoClaim has error!",
+							queue.KeyName, csm.name,
+							queue.KeyTopic, topic,
+							queue.KeyGroup, csm.group,
+							queue.KeyErr, err,
+						)
+					}
+					break
+				}
+				for _, m := range ms {
+					csm.consume(c, topic, m, hdl)
+				}
+				if start = next; start == "0-0" {
+					break
+				}
+			}
+		}
+	}
+}
+
+// xAutoClaim runs XAUTOCLAIM and parses the reply by itself,
+// because the reply of redis 7 has the third element of deleted ids which go-redis v8 rejects.
+func (csm *ConsumerGroup) xAutoClaim(c context.Context, topic, consumer, start string) (ms []redis.XMessage, next string, err error) {
+	var rst []any
+	if rst, err = csm.rdbs.Rdb(c).Do(c, "XAUTOCLAIM", topic, csm.group, consumer,
+		csm.minIdle.Milliseconds(), start, "COUNT", csm.count).Slice(); err != nil {
+		return
+	}
+	if len(rst) < 2 {
+		err = fmt.Errorf("invalid reply of XAUTOCLAIM %v", rst)
+		return
+	}
+	next, _ = rst[0].(string)
+	entries, _ := rst[1].([]any)
+	ms = make([]redis.XMessage, 0, len(entries))
+	for _, e := range entries {
+		// the deleted entry is nil in redis 6.2.
+		kv, ok := e.([]any)
+		if !ok || len(kv) != 2 {
+			continue
+		}
+		m := redis.XMessage{Values: make(map[string]any)}
+		m.ID, _ = kv[0].(string)
+		fields, _ := kv[1].([]any)
+		for i := 0; i+1 < len(fields); i += 2 {
+			k, _ := fields[i].(string)
+			m.Values[k] = fields[i+1]
+		}
+		ms = append(ms, m)
+	}
+	return
+}
+
+// consume XACKs the entry if the handler returns nil, or leaves it pending to be claimed.
+func (csm *ConsumerGroup) consume(c context.Context, topic string, m redis.XMessage, hdl queue.ConsumerHandler) {
+	c = queue.InitHeaderToContext(c)
+	header, _ := queue.GetHeaderFromContext(c)
+	message := qredis.Decode(m.Values, header)
+
+	ps := []any{
+		queue.KeyName, csm.name,
+		queue.KeyTopic, topic,
+		queue.KeyOffset, m.ID,
+		queue.KeyMessage, string(message),
+	}
+
+	begin := time.Now()
+	err := func() (err error) {
+		defer func() {
+			if e := recover(); e != nil {
+				err = fmt.Errorf("panic: %v", e)
+				ps = append(ps, queue.KeyStack, string(debug.Stack()))
+			}
+		}()
+		return hdl(c, message)
+	}()
+	cost := time.Since(begin)
+	ps = append(ps, "cost", cost)
+
+	if err != nil {
+		ps = append(ps, queue.KeyErr, err)
+		csm.logger.Error(c, "Consumer's Has err!", ps...)
+		return
+	}
+
+	if err = csm.rdbs.Rdb(c).XAck(c, topic, csm.group, m.ID).Err(); err != nil {
+		ps = append(ps, queue.KeyErr, err)
+		csm.logger.Error(c, "XAck has error!", ps...)
+		return
+	}
+
+	// slow
+	if cost > csm.slowTime {
+		ps = append(ps,
+			"slowTime", csm.slowTime,
+		)
+		csm.logger.Warn(c, "slowlog", ps...)
+		return
+	}
+
+	csm.logger.Info(c, "", ps...)
+}
+
+// sleep returns false if c is done within d.
+func sleep(c context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-c.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,125 @@
+package consumergroup
+
+/*
+ * @abstract consumer group of redis stream
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	dbredis "github.com/neo532/gokit/database/redis"
+	"github.com/neo532/gokit/queue"
+	"github.com/neo532/gokit/queue/redis/producer"
+)
+
+func TraceID() queue.ProducerMiddleware {
+	return func(handler queue.ProducerHandler) queue.ProducerHandler {
+		return func(c context.Context, message any) (err error) {
+			c = queue.AppendHeaderToContext(c, "traceID", "abc")
+			return handler(c, message)
+		}
+	}
+}
+
+func TestConsumer(t *testing.T) {
+	rdbs := dbredis.News(dbredis.WithDefault(dbredis.New("default", "127.0.0.1:6379")))
+	if err := rdbs.Error(); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+		return
+	}
+	c := context.Background()
+	topic := "queue.redis.testtopic"
+	rdbs.Rdb(c).Del(c, topic)
+
+	pdc := producer.New("default", rdbs, producer.WithTopic(topic), producer.WithMaxLen(1000), producer.WithMiddleware(TraceID()))
+	for i := 0; i < 3; i++ {
+		if err := pdc.Send(c, i); err != nil {
+			t.Errorf("%s has err[%+v]", t.Name(), err)
+			return
+		}
+	}
+
+	var lock sync.Mutex
+	var traceID string
+	var count, fails int
+	done := make(chan struct{})
+	csm, err := NewGroup(
+		"default",
+		rdbs,
+		"sender",
+		WithTopics(topic),
+		WithMinIdle(10*time.Millisecond),
+		WithBatch(10, 100*time.Millisecond),
+		WithHandler(func(c context.Context, message []byte) (err error) {
+			// read and claim run at the same time.
+			lock.Lock()
+			defer lock.Unlock()
+			if h, ok := queue.GetHeaderFromContext(c); ok {
+				traceID = h.Value("traceID")
+			}
+			// fails once and is claimed after min idle.
+			if string(message) == "1" {
+				if fails++; fails == 1 {
+					return errors.New("biz error")
+				}
+			}
+			if count++; count == 3 {
+				close(done)
+			}
+			return
+		}),
+	)
+	if err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+		return
+	}
+	go csm.Start(c)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+	}
+	csm.Stop(c)
+
+	lock.Lock()
+	defer lock.Unlock()
+	if count != 3 || fails != 2 || traceID != "abc" {
+		t.Errorf("%s has err[%d %d %s] should [3 2 abc]", t.Name(), count, fails, traceID)
+	}
+	fmt.Println(t.Name())
+}
+
+func TestRestart(t *testing.T) {
+	rdbs := dbredis.News(dbredis.WithDefault(dbredis.New("default", "127.0.0.1:6379")))
+	if err := rdbs.Error(); err != nil {
+		t.Errorf("%s has err[%+v]", t.Name(), err)
+		return
+	}
+	c := context.Background()
+	topic := "queue.redis.testrestart"
+	// not a stream, so XGROUP CREATE fails.
+	rdbs.Rdb(c).Set(c, topic, "IamNotAStream", 0)
+	defer rdbs.Rdb(c).Del(c, topic)
+
+	csm, _ := NewGroup(
+		"default",
+		rdbs,
+		"sender",
+		WithTopics(topic),
+		WithHandler(func(c context.Context, message []byte) (err error) {
+			return
+		}),
+	)
+	for i := 0; i < 2; i++ {
+		if err := csm.Start(c); err == nil || err.Error() == "Consumer has been started!" {
+			t.Errorf("%s has err[%+v] should [WRONGTYPE]", t.Name(), err)
+		}
+	}
+	fmt.Println(t.Name())
+}
@@ -0,0 +1,71 @@
+package consumergroup
+
+/*
+ * @abstract consumer's option
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"time"
+
+	"github.com/neo532/gokit/logger"
+	"github.com/neo532/gokit/queue"
+)
+
+type Option func(o *ConsumerGroup)
+
+func WithLogger(l logger.ILogger) Option {
+	return func(o *ConsumerGroup) {
+		o.logger = l
+	}
+}
+func WithSlowLog(t time.Duration) Option {
+	return func(o *ConsumerGroup) {
+		o.slowTime = t
+	}
+}
+func WithGoCount(count int) Option {
+	return func(o *ConsumerGroup) {
+		o.goCount = count
+	}
+}
+func WithTopics(s ...string) Option {
+	return func(o *ConsumerGroup) {
+		o.topics = s
+	}
+}
+func WithHandler(fn func(ctx context.Context, message []byte) (err error)) Option {
+	return func(o *ConsumerGroup) {
+		o.handler = fn
+	}
+}
+func WithMiddleware(ms ...queue.ConsumerMiddleware) Option {
+	return func(o *ConsumerGroup) {
+		o.middleware = append(o.middleware, ms...)
+	}
+}
+
+// WithConsumer sets the prefix of the consumer's name in the group, the default is hostname-pid.
+func WithConsumer(name string) Option {
+	return func(o *ConsumerGroup) {
+		o.consumer = name
+	}
+}
+
+// WithBatch sets the count and the block time of every XREADGROUP.
+func WithBatch(count int64, block time.Duration) Option {
+	return func(o *ConsumerGroup) {
+		o.count = count
+		o.block = block
+	}
+}
+
+// WithMinIdle claims the pending entries idle for longer than t by XAUTOCLAIM,
+// such as the failed ones or the ones of the crashed consumer.
+func WithMinIdle(t time.Duration) Option {
+	return func(o *ConsumerGroup) {
+		o.minIdle = t
+	}
+}
@@ -0,0 +1,46 @@
+package producer
+
+/*
+ * @abstract producer's option
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"github.com/neo532/gokit/logger"
+	"github.com/neo532/gokit/queue"
+)
+
+// ========== Option ==========
+type Option func(*Producer)
+
+func WithLogger(l logger.ILogger) Option {
+	return func(o *Producer) {
+		o.logger = l
+	}
+}
+
+func WithTopic(topic string) Option {
+	return func(o *Producer) {
+		o.Topic = topic
+	}
+}
+
+// WithMaxLen trims the stream to about n entries on XADD, 0 means no trimming.
+func WithMaxLen(n int64) Option {
+	return func(o *Producer) {
+		o.MaxLen = n
+	}
+}
+
+func WithEncoder(fn EncodeMessageFunc) Option {
+	return func(o *Producer) {
+		o.encoder = fn
+	}
+}
+
+func WithMiddleware(ms ...queue.ProducerMiddleware) Option {
+	return func(o *Producer) {
+		o.middleware = append(o.middleware, ms...)
+	}
+}
@@ -0,0 +1,127 @@
+package producer
+
+/*
+ * @abstract producer of redis stream
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/go-redis/redis/v8"
+
+	dbredis "github.com/neo532/gokit/database/redis"
+	"github.com/neo532/gokit/logger"
+	"github.com/neo532/gokit/queue"
+	qredis "github.com/neo532/gokit/queue/redis"
+)
+
+var _ queue.Producer = (*Producer)(nil)
+
+type EncodeMessageFunc func(message any) (msg []byte, err error)
+
+func JsonMessageEncoder(message any) (msg []byte, err error) {
+	return json.Marshal(message)
+}
+
+// Producer sends the messages to a redis stream by XADD, the header is stored in the fields of the entry.
+type Producer struct {
+	Name   string
+	Topic  string
+	MaxLen int64
+
+	rdbs       *dbredis.Rediss
+	logger     logger.ILogger
+	encoder    EncodeMessageFunc
+	err        error
+	middleware []queue.ProducerMiddleware
+}
+
+func New(name string, rdbs *dbredis.Rediss, opts ...Option) (pdc *Producer) {
+	pdc = &Producer{
+		Name:       name,
+		rdbs:       rdbs,
+		logger:     logger.NewDefaultILogger(),
+		encoder:    JsonMessageEncoder,
+		middleware: make([]queue.ProducerMiddleware, 0, 1),
+	}
+	for _, o := range opts {
+		o(pdc)
+	}
+
+	switch {
+	case pdc.rdbs == nil:
+		pdc.err = errors.New("Nil redis!")
+	case pdc.rdbs.Error() != nil:
+		pdc.err = pdc.rdbs.Error()
+	}
+	if pdc.err != nil {
+		pdc.logger.Error(context.Background(), "New producer has error!",
+			queue.KeyName, pdc.Name,
+			queue.KeyErr, pdc.err,
+		)
+	}
+	return
+}
+
+func (pdc *Producer) Error() error {
+	return pdc.err
+}
+
+func (pdc *Producer) Send(c context.Context, message any) (err error) {
+	if err = pdc.err; err != nil {
+		return
+	}
+
+	c = queue.InitHeaderToContext(c)
+
+	ps := []any{
+		queue.KeyName, pdc.Name,
+		queue.KeyTopic, pdc.Topic,
+	}
+
+	var msg []byte
+	if msg, err = queue.Encode(message, pdc.encoder); err != nil {
+		ps = append(ps, queue.KeyErr, err, queue.KeyMessage, message)
+		pdc.logger.Error(c, "Producer's encoder Has err!", ps...)
+		return
+	}
+	ps = append(ps, queue.KeyMessage, string(msg))
+
+	h := func(c context.Context, message any) (err error) {
+		header, _ := queue.GetHeaderFromContext(c)
+		args := &redis.XAddArgs{
+			Stream: pdc.Topic,
+			Values: qredis.Encode(msg, header),
+		}
+		if pdc.MaxLen > 0 {
+			args.MaxLen = pdc.MaxLen
+			args.Approx = true
+		}
+
+		var id string
+		id, err = pdc.rdbs.Rdb(c).XAdd(c, args).Result()
+		ps = append(ps, queue.KeyOffset, id)
+		return
+	}
+
+	if len(pdc.middleware) > 0 {
+		h = queue.ChainProducer(pdc.middleware...)(h)
+	}
+
+	if err = h(c, message); err != nil {
+		ps = append(ps, queue.KeyErr, err)
+		pdc.logger.Error(c, "Producer's sending Has err!", ps...)
+		return
+	}
+
+	pdc.logger.Info(c, "Producer have been delivered!", ps...)
+	return
+}
+
+func (pdc *Producer) Close() func() {
+	return func() {}
+}
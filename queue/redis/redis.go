@@ -0,0 +1,46 @@
+package redis
+
+/*
+ * @abstract the fields of the message in redis stream
+ * @mail neo532@126.com
+ * @date 2026-10-17
+ */
+
+import (
+	"strings"
+
+	"github.com/neo532/gokit/queue"
+)
+
+var (
+	// FieldValue is the field of the message's value.
+	FieldValue = "value"
+	// FieldHeaderPrefix is the prefix of the fields of the message's header.
+	FieldHeaderPrefix = "header:"
+)
+
+// Encode returns the fields of XADD by value and header.
+func Encode(value []byte, h queue.Header) (fields map[string]any) {
+	fields = make(map[string]any, len(h)+1)
+	fields[FieldValue] = value
+	for k, v := range h {
+		fields[FieldHeaderPrefix+k] = v
+	}
+	return
+}
+
+// Decode returns value and header from the fields of the stream's entry.
+func Decode(fields map[string]any, h queue.Header) (value []byte) {
+	for k, v := range fields {
+		s, _ := v.(string)
+		switch {
+		case k == FieldValue:
+			value = []byte(s)
+		case strings.HasPrefix(k, FieldHeaderPrefix):
+			if h != nil {
+				h.Set(strings.TrimPrefix(k, FieldHeaderPrefix), s)
+			}
+		}
+	}
+	return
+}